@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"time"
 )
 
@@ -59,6 +60,49 @@ func (ac *AuthClient) RefreshToken(refreshToken, email string) (*http.Response,
 	return ac.client.Do(req)
 }
 
+// GetUserLocale returns the user's preferred locale, or "" when none is set
+func (ac *AuthClient) GetUserLocale(email string) (string, error) {
+	url := fmt.Sprintf("%s/users/locale?email=%s", config.AppConfig.AuthorizationService, neturl.QueryEscape(email))
+	resp, err := ac.client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to call auth service: %w", err)
+	}
+	respBody, err := ReadResponseBody(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("auth service returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse locale response: %w", err)
+	}
+	return response.Locale, nil
+}
+
+// SetUserLocale sends request to auth service to store the user's preferred locale
+func (ac *AuthClient) SetUserLocale(email, locale string) (*http.Response, error) {
+	payload := map[string]string{"email": email, "locale": locale}
+	body, _ := json.Marshal(payload)
+
+	url := fmt.Sprintf("%s/users/locale", config.AppConfig.AuthorizationService)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create locale request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return ac.client.Do(req)
+}
+
 // RefreshAccessToken attempts to refresh the access token using the refresh token
 func (ac *AuthClient) RefreshAccessToken(sessionData map[string]string, log interface{}) (string, error) {
 	// Get refresh token from session data
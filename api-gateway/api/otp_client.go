@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"time"
 )
 
@@ -23,9 +24,26 @@ func NewOTPClient() *OTPClient {
 	}
 }
 
-// RequestOTP sends OTP generation request to OTP service
-func (oc *OTPClient) RequestOTP(email, sessionID string) (*http.Response, error) {
+// OTPRequestOptions are the optional fields of an OTP generation request.
+// Empty values mean the OTP service default.
+type OTPRequestOptions struct {
+	Mode    string // login method: "code", "link" or "totp"
+	Channel string // delivery channel override
+	Locale  string // email language, e.g. "fr-CA"
+}
+
+// RequestOTP sends OTP generation request to OTP service.
+func (oc *OTPClient) RequestOTP(email, sessionID string, opts OTPRequestOptions) (*http.Response, error) {
 	payload := map[string]string{"email": email}
+	if opts.Mode != "" {
+		payload["mode"] = opts.Mode
+	}
+	if opts.Channel != "" {
+		payload["channel"] = opts.Channel
+	}
+	if opts.Locale != "" {
+		payload["locale"] = opts.Locale
+	}
 	body, _ := json.Marshal(payload)
 
 	url := fmt.Sprintf("%s/otp/generate", config.AppConfig.OtpService)
@@ -59,3 +77,113 @@ func (oc *OTPClient) VerifyOTP(otp, email, sessionID string) (*http.Response, er
 
 	return oc.client.Do(req)
 }
+
+// VerifyMagicLink sends a magic-link token verification request to OTP service
+func (oc *OTPClient) VerifyMagicLink(token, email, sessionID string) (*http.Response, error) {
+	payload := map[string]string{
+		"token": token,
+		"email": email,
+	}
+	body, _ := json.Marshal(payload)
+
+	url := fmt.Sprintf("%s/otp/verify-link", config.AppConfig.OtpService)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create magic link verification request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Session-ID", sessionID)
+
+	return oc.client.Do(req)
+}
+
+// EnrollTOTP starts authenticator app enrollment for email
+func (oc *OTPClient) EnrollTOTP(email string) (*http.Response, error) {
+	return oc.postJSON("/otp/totp/enroll", map[string]string{"email": email}, "")
+}
+
+// ConfirmTOTP confirms a pending authenticator enrollment with a first code
+func (oc *OTPClient) ConfirmTOTP(email, code string) (*http.Response, error) {
+	return oc.postJSON("/otp/totp/confirm", map[string]string{"email": email, "code": code}, "")
+}
+
+// VerifyTOTP checks an authenticator code for an enrolled user (step-up)
+func (oc *OTPClient) VerifyTOTP(email, code string) (*http.Response, error) {
+	return oc.postJSON("/otp/totp/verify", map[string]string{"email": email, "code": code}, "")
+}
+
+// TOTPEnrolled reports whether email has a confirmed authenticator enrollment
+func (oc *OTPClient) TOTPEnrolled(email string) (bool, error) {
+	resp, err := oc.client.Get(config.AppConfig.OtpService + "/otp/totp/status?email=" + neturl.QueryEscape(email))
+	if err != nil {
+		return false, fmt.Errorf("failed to call OTP service: %w", err)
+	}
+	respBody, err := ReadResponseBody(resp)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("OTP service returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response struct {
+		Enrolled bool `json:"enrolled"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return false, fmt.Errorf("failed to parse TOTP status response: %w", err)
+	}
+	return response.Enrolled, nil
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of email with a fresh set
+func (oc *OTPClient) GenerateRecoveryCodes(email string) (*http.Response, error) {
+	return oc.postJSON("/otp/recovery-codes", map[string]string{"email": email}, "")
+}
+
+// VerifyRecoveryCode redeems a recovery code in place of an OTP for the given session
+func (oc *OTPClient) VerifyRecoveryCode(code, email, sessionID string) (*http.Response, error) {
+	return oc.postJSON("/otp/recovery/verify", map[string]string{"email": email, "code": code}, sessionID)
+}
+
+// SetDeliveryPreference stores the channel (and phone) OTPs should be sent to for email
+func (oc *OTPClient) SetDeliveryPreference(email, channel, phone string) (*http.Response, error) {
+	return oc.postJSON("/otp/delivery-preference", map[string]string{"email": email, "channel": channel, "phone": phone}, "")
+}
+
+// VerifyDeliveryPreference confirms a pending SMS or voice preference with the code sent to the phone
+func (oc *OTPClient) VerifyDeliveryPreference(email, code string) (*http.Response, error) {
+	return oc.postJSON("/otp/delivery-preference/verify", map[string]string{"email": email, "code": code}, "")
+}
+
+// DeliveryStatus asks the OTP service whether the last OTP or link for sessionID was delivered
+func (oc *OTPClient) DeliveryStatus(sessionID string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", config.AppConfig.OtpService+"/otp/delivery-status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delivery status request: %w", err)
+	}
+	req.Header.Set("X-Session-ID", sessionID)
+
+	return oc.client.Do(req)
+}
+
+// postJSON sends a JSON POST to the OTP service, adding X-Session-ID when sessionID is set
+func (oc *OTPClient) postJSON(path string, payload interface{}, sessionID string) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OTP service request: %w", err)
+	}
+
+	url := config.AppConfig.OtpService + path
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTP service request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set("X-Session-ID", sessionID)
+	}
+
+	return oc.client.Do(req)
+}
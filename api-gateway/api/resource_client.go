@@ -71,10 +71,12 @@ func (rc *ResourceClient) ForwardToResourceAPI(c *gin.Context, claims *utils.Cus
 		return err
 	}
 
-	// Copy response headers
+	// Copy response headers unchanged, so ETag and the rest of the conditional
+	// request headers reach the client; request headers such as If-Match and
+	// If-None-Match were copied above
 	for key, values := range resp.Header {
 		for _, value := range values {
-			c.Header(key, value)
+			c.Writer.Header().Add(key, value)
 		}
 	}
 
@@ -23,10 +23,11 @@ type Config struct {
 	SessionTTLHours int // in hours
 
 	// App
-	AppEnv       string
-	AuditTTLDays int
-	RateLimit    int
-	JWTSecret    string
+	AppEnv           string
+	AuditTTLDays     int
+	RateLimit        int
+	JWTSecret        string
+	StepUpTTLMinutes int // how long a TOTP step-up unlocks sensitive routes
 
 	//Deployed Services
 	OtpService           string
@@ -95,6 +96,11 @@ func InitConfig() {
 		log.Fatalf("Rate_Limit_Per_Minute: %v", err)
 	}
 
+	AppConfig.StepUpTTLMinutes, err = parseEnvInt("STEP_UP_TTL_MINUTES", 10)
+	if err != nil || AppConfig.StepUpTTLMinutes < 1 {
+		log.Fatalf("Invalid STEP_UP_TTL_MINUTES: must be a positive number of minutes")
+	}
+
 	AppConfig.ApiGatewayPort, err = parseEnvInt("API_GATEWAY_PORT", 8080)
 	if err != nil {
 		log.Fatalf("API_GATEWAY_PORT: %v", err)
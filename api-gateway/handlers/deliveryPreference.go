@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type deliveryPreferenceRequest struct {
+	Channel string `json:"channel"`
+	Phone   string `json:"phone"`
+}
+
+// DeliveryPreferenceHandler sets the channel the logged-in user receives OTPs on.
+// A new phone number is answered with 202 until it is confirmed via DeliveryPreferenceVerifyHandler.
+// Users with an authenticator app must have stepped up with /totp/verify first.
+func DeliveryPreferenceHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, sessionID, ok := authenticateSession(c, log, reqCtx, models.ActionDeliveryPreferenceSet)
+	if !ok || !requireStepUp(c, log, reqCtx, models.ActionDeliveryPreferenceSet, claims, sessionID) {
+		return
+	}
+
+	var req deliveryPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Channel == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing channel"})
+		return
+	}
+
+	resp, err := otpClient.SetDeliveryPreference(claims.Email, req.Channel, req.Phone)
+	relayOTPResponse(c, log, reqCtx, models.ActionDeliveryPreferenceSet, claims, resp, err)
+}
+
+// DeliveryPreferenceVerifyHandler confirms the logged-in user's new phone number with the code sent to it
+func DeliveryPreferenceVerifyHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, _, ok := authenticateSession(c, log, reqCtx, models.ActionDeliveryPreferenceVerified)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code"})
+		return
+	}
+
+	resp, err := otpClient.VerifyDeliveryPreference(claims.Email, req.Code)
+	relayOTPResponse(c, log, reqCtx, models.ActionDeliveryPreferenceVerified, claims, resp, err)
+}
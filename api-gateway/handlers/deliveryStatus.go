@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/redis"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeliveryStatusHandler tells a user waiting for an OTP or magic link whether
+// it has been delivered, using the sessionId cookie set at signup
+func DeliveryStatusHandler(c *gin.Context) {
+	log := utils.NewLogger()
+
+	sessionID, err := c.Cookie("sessionId")
+	if err != nil || sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing sessionId cookie"})
+		return
+	}
+
+	sessionData, err := redis.GetSessionData(sessionID)
+	if err != nil || len(sessionData) == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		return
+	}
+	if sessionData["clientID"] != c.ClientIP() {
+		log.Warn("Session clientID mismatch: got %s, expected %s", sessionData["clientID"], c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session does not belong to this client"})
+		return
+	}
+
+	resp, err := api.NewOTPClient().DeliveryStatus(sessionID)
+	if err != nil {
+		log.Error("OTP service request failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "OTP service unreachable"})
+		return
+	}
+
+	respBody, _ := api.ReadResponseBody(resp)
+	c.Data(resp.StatusCode, "application/json", respBody)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type localeRequest struct {
+	Locale string `json:"locale"`
+}
+
+// LocaleHandler sets the language the logged-in user receives emails in;
+// an empty locale clears it so the browser's Accept-Language is used
+func LocaleHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	authClient := api.NewAuthClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, _, ok := authenticateSession(c, log, reqCtx, models.ActionLocaleSet)
+	if !ok {
+		return
+	}
+
+	var req localeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Locale != "" && !utils.IsValidLocale(req.Locale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid locale"})
+		return
+	}
+
+	resp, err := authClient.SetUserLocale(claims.Email, req.Locale)
+	if err != nil {
+		log.Error("Auth service request failed: %v", err)
+
+		msg := "Auth service unreachable"
+		auditEntry := log.NewAuditEntry(models.EventGroupAuth, models.ActionLocaleSet, &claims.UserID, nil, reqCtx, http.StatusBadGateway, &msg)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
+		return
+	}
+
+	respBody, _ := api.ReadResponseBody(resp)
+	auditEntry := log.NewAuditEntry(models.EventGroupAuth, models.ActionLocaleSet, &claims.UserID, nil, reqCtx, resp.StatusCode, nil)
+	log.LogAuditEntry(auditEntry)
+
+	c.Data(resp.StatusCode, "application/json", respBody)
+}
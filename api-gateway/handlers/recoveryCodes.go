@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryCodesHandler generates a fresh set of recovery codes for the logged-in user.
+// Any previous codes stop working; the new ones are only shown in this response.
+// Users with an authenticator app must have stepped up with /totp/verify first.
+func RecoveryCodesHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, sessionID, ok := authenticateSession(c, log, reqCtx, models.ActionRecoveryCodesGenerated)
+	if !ok || !requireStepUp(c, log, reqCtx, models.ActionRecoveryCodesGenerated, claims, sessionID) {
+		return
+	}
+
+	resp, err := otpClient.GenerateRecoveryCodes(claims.Email)
+	relayOTPResponse(c, log, reqCtx, models.ActionRecoveryCodesGenerated, claims, resp, err)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/redis"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authenticateSession resolves the sessionId cookie to a logged-in session and
+// validates its access token, refreshing it once if it has expired.
+// On failure it audits the attempt under action, writes the response and returns false.
+func authenticateSession(c *gin.Context, log *utils.Logger, reqCtx models.RequestContext, action models.EventAction) (*utils.CustomClaims, string, bool) {
+	authClient := api.NewAuthClient()
+
+	// 1. Extract sessionId from cookie
+	sessionID, err := c.Cookie("sessionId")
+	if err != nil {
+		log.Warn("Missing sessionId cookie")
+
+		msg := "Missing session ID"
+		auditEntry := log.NewAuditEntry(
+			models.EventGroupAuth,
+			action,
+			nil,
+			nil,
+			reqCtx,
+			http.StatusUnauthorized,
+			&msg,
+		)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return nil, "", false
+	}
+
+	// 2. Get access token from Redis using sessionId
+	sessionData, err := redis.GetSessionData(sessionID)
+	if err != nil {
+		log.Warn("Failed to get session data: %v", err)
+
+		msg := "Invalid session"
+		auditEntry := log.NewAuditEntry(
+			models.EventGroupAuth,
+			action,
+			nil,
+			nil,
+			reqCtx,
+			http.StatusUnauthorized,
+			&msg,
+		)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return nil, "", false
+	}
+
+	// Check if access token exists in session data
+	accessToken, exists := sessionData["token"]
+	if !exists || accessToken == "" {
+		log.Warn("No access token found in session")
+
+		msg := "No access token found"
+		auditEntry := log.NewAuditEntry(
+			models.EventGroupAuth,
+			action,
+			nil,
+			nil,
+			reqCtx,
+			http.StatusUnauthorized,
+			&msg,
+		)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return nil, "", false
+	}
+
+	// 3. Validate JWT token
+	claims, err := utils.ValidateJWTToken(accessToken)
+	if err != nil {
+		// Check if token is expired by checking the error message
+		if err.Error() == "token has expired" || err.Error() == "Token is expired" {
+			log.Info("Access token expired, attempting to refresh")
+
+			// Try to refresh the token
+			newAccessToken, err := authClient.RefreshAccessToken(sessionData, log)
+			if err != nil {
+				log.Warn("Failed to refresh access token: %v", err)
+
+				// Delete session data since refresh failed
+				redis.DeleteSession(sessionID)
+
+				msg := "Session expired, please login again"
+				auditEntry := log.NewAuditEntry(
+					models.EventGroupAuth,
+					action,
+					nil,
+					nil,
+					reqCtx,
+					http.StatusUnauthorized,
+					&msg,
+				)
+				log.LogAuditEntry(auditEntry)
+
+				c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+				return nil, "", false
+			}
+
+			// Update session data with new access token
+			if err := redis.UpdateSessionField(sessionID, "token", newAccessToken); err != nil {
+				log.Error("Failed to update session with new access token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+				return nil, "", false
+			}
+
+			// Validate the new token
+			claims, err = utils.ValidateJWTToken(newAccessToken)
+			if err != nil {
+				log.Warn("New access token validation failed: %v", err)
+				msg := "Invalid access token"
+				auditEntry := log.NewAuditEntry(
+					models.EventGroupAuth,
+					action,
+					nil,
+					nil,
+					reqCtx,
+					http.StatusUnauthorized,
+					&msg,
+				)
+				log.LogAuditEntry(auditEntry)
+
+				c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+				return nil, "", false
+			}
+		} else {
+			log.Warn("JWT token validation failed: %v", err)
+
+			msg := "Invalid access token"
+			auditEntry := log.NewAuditEntry(
+				models.EventGroupAuth,
+				action,
+				nil,
+				nil,
+				reqCtx,
+				http.StatusUnauthorized,
+				&msg,
+			)
+			log.LogAuditEntry(auditEntry)
+
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return nil, "", false
+		}
+	}
+
+	return claims, sessionID, true
+}
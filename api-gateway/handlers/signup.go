@@ -17,6 +17,7 @@ import (
 func SignUpHandler(c *gin.Context) {
 	log := utils.NewLogger()
 	otpClient := api.NewOTPClient()
+	authClient := api.NewAuthClient()
 
 	// Extract request context info
 	reqCtx := models.RequestContext{
@@ -28,6 +29,10 @@ func SignUpHandler(c *gin.Context) {
 	// Parse email from body
 	var body struct {
 		Email string `json:"email"`
+		Mode  string `json:"mode"` // "code" (default), "link" or "totp"
+		// Optional delivery override, limited to the channels the user's preference allows.
+		// There is no phone field: SMS and voice go to the phone verified for the preference.
+		Channel string `json:"channel"` // "email", "sms", "voice" or "webhook"
 	}
 	if err := c.ShouldBindJSON(&body); err != nil || body.Email == "" {
 		log.Warn("Missing email in request body")
@@ -58,6 +63,24 @@ func SignUpHandler(c *gin.Context) {
 		return
 	}
 
+	// Validate login mode
+	if body.Mode != "" && body.Mode != "code" && body.Mode != "link" && body.Mode != "totp" {
+		log.Warn("Invalid login mode: %s", body.Mode)
+
+		msg := "Invalid mode, expected \"code\", \"link\" or \"totp\""
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Validate delivery channel
+	if body.Channel != "" && body.Channel != "email" && body.Channel != "sms" && body.Channel != "voice" && body.Channel != "webhook" {
+		log.Warn("Invalid delivery channel: %s", body.Channel)
+
+		msg := "Invalid channel, expected \"email\", \"sms\", \"voice\" or \"webhook\""
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	// Generate session ID
 	sessionID, err := utils.GenerateSessionID()
 	if err != nil {
@@ -90,8 +113,21 @@ func SignUpHandler(c *gin.Context) {
 	}
 	http.SetCookie(c.Writer, cookie)
 
+	// Email language: the user's stored preference, else the browser's
+	locale, err := authClient.GetUserLocale(body.Email)
+	if err != nil {
+		log.Warn("Failed to load user locale: %v", err)
+	}
+	if locale == "" {
+		locale = utils.PreferredLocale(c.GetHeader("Accept-Language"))
+	}
+
 	// Request OTP using OTP client
-	resp, err := otpClient.RequestOTP(body.Email, sessionID)
+	resp, err := otpClient.RequestOTP(body.Email, sessionID, api.OTPRequestOptions{
+		Mode:    body.Mode,
+		Channel: body.Channel,
+		Locale:  locale,
+	})
 	if err != nil {
 		log.Error("Request to OTP service failed: %v", err)
 
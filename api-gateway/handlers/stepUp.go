@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/config"
+	"api-gateway/models"
+	"api-gateway/redis"
+	"api-gateway/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stepUpField is the session hash field holding the Unix time of the last
+// successful TOTP step-up
+const stepUpField = "stepUpAt"
+
+// recordStepUp marks the session as stepped up as of now
+func recordStepUp(sessionID string) error {
+	return redis.UpdateSessionField(sessionID, stepUpField, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// steppedUp reports whether the session passed a TOTP step-up within STEP_UP_TTL_MINUTES
+func steppedUp(sessionID string) bool {
+	sessionData, err := redis.GetSessionData(sessionID)
+	if err != nil {
+		return false
+	}
+	at, err := strconv.ParseInt(sessionData[stepUpField], 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Since(time.Unix(at, 0)) < time.Duration(config.AppConfig.StepUpTTLMinutes)*time.Minute
+}
+
+// requireStepUp lets a sensitive request through when the session has stepped
+// up recently or the user has no authenticator to step up with. Otherwise it
+// audits the attempt under action, answers 403 and returns false.
+func requireStepUp(c *gin.Context, log *utils.Logger, reqCtx models.RequestContext, action models.EventAction, claims *utils.CustomClaims, sessionID string) bool {
+	if steppedUp(sessionID) {
+		return true
+	}
+
+	enrolled, err := api.NewOTPClient().TOTPEnrolled(claims.Email)
+	if err != nil {
+		log.Error("TOTP status check failed: %v", err)
+
+		msg := "OTP service unreachable"
+		auditEntry := log.NewAuditEntry(models.EventGroupAuth, action, &claims.UserID, nil, reqCtx, http.StatusBadGateway, &msg)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
+		return false
+	}
+	if !enrolled {
+		return true
+	}
+
+	msg := "Step-up required"
+	auditEntry := log.NewAuditEntry(models.EventGroupAuth, action, &claims.UserID, nil, reqCtx, http.StatusForbidden, &msg)
+	log.LogAuditEntry(auditEntry)
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Verify an authenticator code with /totp/verify first", "step_up_required": true})
+	return false
+}
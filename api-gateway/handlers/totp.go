@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TOTPEnrollHandler starts authenticator app enrollment for the logged-in user
+func TOTPEnrollHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, _, ok := authenticateSession(c, log, reqCtx, models.ActionTOTPEnrolled)
+	if !ok {
+		return
+	}
+
+	resp, err := otpClient.EnrollTOTP(claims.Email)
+	relayOTPResponse(c, log, reqCtx, models.ActionTOTPEnrolled, claims, resp, err)
+}
+
+// TOTPConfirmHandler confirms enrollment with the first code from the authenticator app
+func TOTPConfirmHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, _, ok := authenticateSession(c, log, reqCtx, models.ActionTOTPConfirmed)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code"})
+		return
+	}
+
+	resp, err := otpClient.ConfirmTOTP(claims.Email, req.Code)
+	relayOTPResponse(c, log, reqCtx, models.ActionTOTPConfirmed, claims, resp, err)
+}
+
+// TOTPStepUpHandler re-verifies a logged-in user with an authenticator code. A
+// correct code is recorded on the session, unlocking the routes guarded by
+// requireStepUp for STEP_UP_TTL_MINUTES.
+func TOTPStepUpHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	claims, sessionID, ok := authenticateSession(c, log, reqCtx, models.ActionStepUp)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code"})
+		return
+	}
+
+	resp, err := otpClient.VerifyTOTP(claims.Email, req.Code)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		if err := recordStepUp(sessionID); err != nil {
+			log.Error("Failed to record step-up: %v", err)
+			resp.Body.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+			return
+		}
+	}
+	relayOTPResponse(c, log, reqCtx, models.ActionStepUp, claims, resp, err)
+}
+
+// relayOTPResponse audits an OTP service call and passes its response through to the client
+func relayOTPResponse(c *gin.Context, log *utils.Logger, reqCtx models.RequestContext, action models.EventAction, claims *utils.CustomClaims, resp *http.Response, err error) {
+	if err != nil {
+		log.Error("OTP service request failed: %v", err)
+
+		msg := "OTP service unreachable"
+		auditEntry := log.NewAuditEntry(models.EventGroupAuth, action, &claims.UserID, nil, reqCtx, http.StatusBadGateway, &msg)
+		log.LogAuditEntry(auditEntry)
+
+		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
+		return
+	}
+
+	respBody, _ := api.ReadResponseBody(resp)
+	auditEntry := log.NewAuditEntry(models.EventGroupAuth, action, &claims.UserID, nil, reqCtx, resp.StatusCode, nil)
+	log.LogAuditEntry(auditEntry)
+
+	c.Data(resp.StatusCode, "application/json", respBody)
+}
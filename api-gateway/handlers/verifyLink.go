@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"api-gateway/api"
+	"api-gateway/models"
+	"api-gateway/redis"
+	"api-gateway/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMagicLinkHandler completes a passwordless login from the link sent by email.
+// The token is bound to the signup session, so the link must be opened by the same client.
+func VerifyMagicLinkHandler(c *gin.Context) {
+	log := utils.NewLogger()
+	otpClient := api.NewOTPClient()
+
+	// Extract request context info
+	reqCtx := models.RequestContext{
+		IP:     c.ClientIP(),
+		Method: c.Request.Method,
+		Path:   c.FullPath(),
+	}
+
+	// Prepare audit log entry base
+	audit := log.NewAuditEntry(
+		models.EventGroupAuth,
+		models.ActionMagicLinkVerified,
+		nil,
+		nil,
+		reqCtx,
+		http.StatusOK,
+		nil,
+	)
+
+	// Step 1: Get token from query
+	token := c.Query("token")
+	if token == "" {
+		log.Warn("Missing magic link token")
+
+		msg := "Missing token"
+		audit.StatusCode = http.StatusBadRequest
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Step 2: Get sessionId from cookie
+	sessionID, err := c.Cookie("sessionId")
+	if err != nil || sessionID == "" {
+		log.Warn("Missing sessionId cookie")
+
+		msg := "Missing sessionId cookie"
+		audit.StatusCode = http.StatusBadRequest
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	audit.SessionID = &sessionID
+
+	// Step 3: Validate session from Redis
+	clientID := c.ClientIP()
+	sessionData, err := redis.GetSessionData(sessionID)
+	if err != nil || len(sessionData) == 0 {
+		log.Warn("Invalid sessionId: %s", sessionID)
+
+		msg := "Invalid session"
+		audit.StatusCode = http.StatusUnauthorized
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return
+	}
+	if sessionData["clientID"] != clientID {
+		log.Warn("Session clientID mismatch: got %s, expected %s", sessionData["clientID"], clientID)
+
+		msg := "Session does not belong to this client"
+		audit.StatusCode = http.StatusUnauthorized
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		return
+	}
+
+	email := sessionData["email"]
+	if email == "" {
+		log.Warn("Missing email in session data")
+		msg := "Email not found in session"
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		return
+	}
+
+	// Step 4: Verify token using API client
+	resp, err := otpClient.VerifyMagicLink(token, email, sessionID)
+	if err != nil {
+		log.Error("OTP service request failed: %v", err)
+
+		msg := "OTP service unreachable"
+		audit.StatusCode = http.StatusBadGateway
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
+		return
+	}
+
+	respBody, _ := api.ReadResponseBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		log.Warn("Magic link verification failed with status=%d", resp.StatusCode)
+
+		msg := "Magic link verification failed"
+		audit.StatusCode = resp.StatusCode
+		audit.Message = &msg
+		log.LogAuditEntry(audit)
+
+		c.Data(resp.StatusCode, "application/json", respBody)
+		return
+	}
+
+	// Steps 5+: same as OTP verification
+	completeLogin(c, log, &audit, sessionID, clientID, email, "Magic link verification successful")
+}
@@ -12,14 +12,14 @@ import (
 )
 
 type otpVerifyRequest struct {
-	OTP   string `json:"otp"`
-	Email string `json:"email"`
+	OTP          string `json:"otp"`
+	Email        string `json:"email"`
+	RecoveryCode string `json:"recovery_code"`
 }
 
 func VerifyOTPHandler(c *gin.Context) {
 	log := utils.NewLogger()
 	otpClient := api.NewOTPClient()
-	authClient := api.NewAuthClient()
 
 	// Extract request context info
 	reqCtx := models.RequestContext{
@@ -97,7 +97,7 @@ func VerifyOTPHandler(c *gin.Context) {
 
 	// Step 4: Parse OTP from request
 	var otpReq otpVerifyRequest
-	if err := c.ShouldBindJSON(&otpReq); err != nil || otpReq.OTP == "" {
+	if err := c.ShouldBindJSON(&otpReq); err != nil || (otpReq.OTP == "" && otpReq.RecoveryCode == "") {
 		log.Warn("Invalid OTP payload")
 
 		msg := "Invalid OTP payload"
@@ -109,8 +109,14 @@ func VerifyOTPHandler(c *gin.Context) {
 		return
 	}
 
-	// Step 5: Verify OTP using API client
-	resp, err := otpClient.VerifyOTP(otpReq.OTP, email, sessionID)
+	// Step 5: Verify OTP (or a recovery code in its place) using API client
+	var resp *http.Response
+	if otpReq.RecoveryCode != "" {
+		audit.EventAction = models.ActionRecoveryCodeUsed
+		resp, err = otpClient.VerifyRecoveryCode(otpReq.RecoveryCode, email, sessionID)
+	} else {
+		resp, err = otpClient.VerifyOTP(otpReq.OTP, email, sessionID)
+	}
 	if err != nil {
 		log.Error("OTP service request failed: %v", err)
 
@@ -137,13 +143,23 @@ func VerifyOTPHandler(c *gin.Context) {
 		return
 	}
 
-	// Step 7: Delete session after successful OTP verification
+	// Steps 7-15: exchange the verified session for tokens and a new sessionId
+	completeLogin(c, log, &audit, sessionID, clientID, email, "OTP verification successful")
+}
+
+// completeLogin finishes a verified login: it drops the signup session, obtains
+// tokens from the auth service and issues a new sessionId cookie.
+// Shared by OTP and magic-link verification.
+func completeLogin(c *gin.Context, log *utils.Logger, audit *models.AuditLog, sessionID, clientID, email, successMsg string) {
+	authClient := api.NewAuthClient()
+
+	// Step 7: Delete session after successful verification
 	if err := redis.DeleteSession(sessionID); err != nil {
-		log.Error("Failed to delete session after OTP verification: %v", err)
-		// Continue anyway as OTP verification was successful
+		log.Error("Failed to delete session after verification: %v", err)
+		// Continue anyway as verification was successful
 	}
 
-	log.Info("OTP verified successfully for sessionId=%s", sessionID)
+	log.Info("Login verified successfully for sessionId=%s", sessionID)
 
 	// Step 8: Delete the previous sessionId from the cookie
 	c.SetCookie("sessionId", "", -1, "/", "", true, true)
@@ -156,7 +172,7 @@ func VerifyOTPHandler(c *gin.Context) {
 		msg := "Auth service unreachable"
 		audit.StatusCode = http.StatusBadGateway
 		audit.Message = &msg
-		log.LogAuditEntry(audit)
+		log.LogAuditEntry(*audit)
 
 		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
 		return
@@ -170,7 +186,7 @@ func VerifyOTPHandler(c *gin.Context) {
 		msg := "Failed to get access token"
 		audit.StatusCode = authResp.StatusCode
 		audit.Message = &msg
-		log.LogAuditEntry(audit)
+		log.LogAuditEntry(*audit)
 
 		c.JSON(authResp.StatusCode, gin.H{"error": msg})
 		return
@@ -222,13 +238,12 @@ func VerifyOTPHandler(c *gin.Context) {
 	http.SetCookie(c.Writer, newCookie)
 
 	// Step 15: Respond with HTTP status 200 OK and a success message
-	msg := "OTP verification successful"
 	audit.StatusCode = http.StatusOK
-	audit.Message = &msg
-	log.LogAuditEntry(audit)
+	audit.Message = &successMsg
+	log.LogAuditEntry(*audit)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "OTP verification successful",
+		"message": successMsg,
 		"status":  "success",
 	})
 }
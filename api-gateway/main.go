@@ -63,13 +63,46 @@ func main() {
 
 	r.POST("/signup", handlers.SignUpHandler)
 	r.POST("/verify-otp", handlers.VerifyOTPHandler)
+	r.GET("/verify-link", handlers.VerifyMagicLinkHandler)
+	r.GET("/delivery-status", handlers.DeliveryStatusHandler)
+
+	// Authenticator app (TOTP) routes, require a logged-in session
+	r.POST("/totp/enroll", handlers.TOTPEnrollHandler)
+	r.POST("/totp/confirm", handlers.TOTPConfirmHandler)
+	r.POST("/totp/verify", handlers.TOTPStepUpHandler)
+
+	// Recovery codes, require a logged-in session
+	r.POST("/recovery-codes", handlers.RecoveryCodesHandler)
+
+	// OTP delivery preference, requires a logged-in session
+	r.POST("/delivery-preference", handlers.DeliveryPreferenceHandler)
+	r.POST("/delivery-preference/verify", handlers.DeliveryPreferenceVerifyHandler)
+	r.POST("/locale", handlers.LocaleHandler)
 
 	// Resource routes
 	r.GET("/resources", handlers.ResourceHandler)
 	r.GET("/resources/:id", handlers.ResourceHandler)
 	r.POST("/resources", handlers.ResourceHandler)
 	r.PUT("/resources/:id", handlers.ResourceHandler)
+	r.PATCH("/resources/:id", handlers.ResourceHandler)
 	r.DELETE("/resources/:id", handlers.ResourceHandler)
+	r.GET("/resources/:id/versions", handlers.ResourceHandler)
+	r.GET("/resources/:id/versions/:v", handlers.ResourceHandler)
+	r.POST("/resources/:id/versions/:v/restore", handlers.ResourceHandler)
+	r.GET("/resources/trash", handlers.ResourceHandler)
+	r.POST("/resources/:id/undelete", handlers.ResourceHandler)
+	r.GET("/resources/:id/shares", handlers.ResourceHandler)
+	r.POST("/resources/:id/shares", handlers.ResourceHandler)
+	r.DELETE("/resources/:id/shares/:type/:principal", handlers.ResourceHandler)
+	r.GET("/resources/:id/acl-events", handlers.ResourceHandler)
+
+	// Group routes, for sharing resources with every member of a group
+	r.GET("/groups", handlers.ResourceHandler)
+	r.POST("/groups", handlers.ResourceHandler)
+	r.GET("/groups/:id", handlers.ResourceHandler)
+	r.POST("/groups/:id/members", handlers.ResourceHandler)
+	r.DELETE("/groups/:id/members/:user_id", handlers.ResourceHandler)
+	r.GET("/groups/:id/events", handlers.ResourceHandler)
 
 	srv := &http.Server{
 		Addr:    ":" + strconv.Itoa(config.AppConfig.ApiGatewayPort),
@@ -24,6 +24,15 @@ const (
 	ActionLogin        EventAction = "LOGIN"
 	ActionOTPGenerated EventAction = "OTP_GENERATED"
 	ActionOTPVerified  EventAction = "OTP_VERIFIED"
+	ActionMagicLinkVerified EventAction = "MAGIC_LINK_VERIFIED"
+	ActionTOTPEnrolled      EventAction = "TOTP_ENROLLED"
+	ActionTOTPConfirmed     EventAction = "TOTP_CONFIRMED"
+	ActionStepUp            EventAction = "STEP_UP"
+	ActionRecoveryCodesGenerated EventAction = "RECOVERY_CODES_GENERATED"
+	ActionRecoveryCodeUsed       EventAction = "RECOVERY_CODE_USED"
+	ActionDeliveryPreferenceSet  EventAction = "DELIVERY_PREFERENCE_SET"
+	ActionDeliveryPreferenceVerified EventAction = "DELIVERY_PREFERENCE_VERIFIED"
+	ActionLocaleSet              EventAction = "LOCALE_SET"
 	ActionAuthFailed   EventAction = "AUTH_FAILED"
 
 	// SESSION group
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// localePattern accepts BCP 47 style tags such as "fr", "pt-BR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// IsValidLocale reports whether locale looks like a BCP 47 language tag
+func IsValidLocale(locale string) bool {
+	return len(locale) <= 35 && localePattern.MatchString(locale)
+}
+
+// PreferredLocale returns the highest-weighted language tag of an
+// Accept-Language header, e.g. "fr-CA" for "fr-CA,fr;q=0.9,en;q=0.8".
+// Wildcards and malformed tags are ignored; "" means no preference.
+func PreferredLocale(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if !IsValidLocale(tag) {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
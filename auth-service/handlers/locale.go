@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"auth-server/config"
+	"auth-server/models"
+	"auth-server/utils"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// localePattern accepts BCP 47 style tags such as "fr", "pt-BR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+type SetLocaleRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Locale string `json:"locale" binding:"max=35"`
+}
+
+// GetUserLocale returns the preferred email language of a user
+func GetUserLocale(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	var user models.User
+	err := config.UserDB.Select("locale").Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		utils.NewLogger().Warn("Failed to load user locale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load locale"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locale": user.Locale})
+}
+
+// SetUserLocale stores the preferred email language of a user; an empty
+// locale clears it so emails fall back to the request's language
+func SetUserLocale(c *gin.Context) {
+	var req SetLocaleRequest
+	logger := utils.NewLogger()
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Invalid request body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email or locale"})
+		return
+	}
+	if req.Locale != "" && !localePattern.MatchString(req.Locale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid locale"})
+		return
+	}
+
+	var user models.User
+	if err := config.UserDB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		logger.Warn("Failed to load user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update locale"})
+		return
+	}
+
+	if err := config.UserDB.Model(&user).Update("locale", req.Locale).Error; err != nil {
+		logger.Warn("Failed to update locale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update locale"})
+		return
+	}
+
+	logger.LogAuditRecord(models.AuditRecord{
+		UserID:      user.ID,
+		Action:      models.LocaleUpdated,
+		Status:      models.StatusSuccess,
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Description: "Preferred locale set to \"" + req.Locale + "\".",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"locale": req.Locale})
+}
@@ -51,6 +51,8 @@ func main() {
 	// Routes placeholder
 	r.POST("/getAccessToken", handlers.GetAccessToken)
 	r.POST("/refreshToken", handlers.RefreshAccessToken)
+	r.GET("/users/locale", handlers.GetUserLocale)
+	r.POST("/users/locale", handlers.SetUserLocale)
 
 	// Start server in a goroutine
 	go func() {
@@ -15,6 +15,7 @@ const (
 	TokenIssued      ActionType = "TOKEN_ISSUED"
 	TokenRevoked     ActionType = "TOKEN_REVOKED"
 	PermissionCheck  ActionType = "PERMISSION_CHECK"
+	LocaleUpdated    ActionType = "LOCALE_UPDATED"
 )
 
 // StatusType defines the outcome of an action
@@ -15,6 +15,7 @@ type User struct {
 	ID        string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
 	Role      UserRole       `gorm:"type:user_role;default:'user'" json:"role"`
+	Locale    string         `gorm:"type:varchar(35);not null;default:''" json:"locale"` // preferred email language, e.g. "fr-CA"
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 }
@@ -3,6 +3,7 @@ module email-service
 go 1.24.5
 
 require (
+	github.com/emersion/go-msgauth v0.6.8
 	github.com/gin-gonic/gin v1.10.1
 	github.com/go-mail/mail v2.3.1+incompatible
 	github.com/joho/godotenv v1.5.1
@@ -10,6 +11,8 @@ require (
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/streadway/amqp v1.1.0
 	github.com/ulule/limiter/v3 v3.11.2
+	golang.org/x/net v0.25.0
+	golang.org/x/time v0.5.0
 	gorm.io/driver/postgres v1.6.0
 	gorm.io/gorm v1.30.0
 )
@@ -47,7 +50,6 @@ require (
 	github.com/ugorji/go/codec v1.2.12 // indirect
 	golang.org/x/arch v0.8.0 // indirect
 	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/mailer"
+	"email-service/internal/queue"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDLQLimit = 20
+	maxDLQLimit     = 500
+)
+
+// InspectDLQHandler lists dead-lettered email jobs without removing them
+func InspectDLQHandler(c *gin.Context) {
+	limit := parseLimit(c)
+
+	letters, total, err := queue.InspectDeadLetters(limit)
+	if err != nil {
+		logger.Error("Failed to inspect DLQ: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect dead-letter queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": total, "messages": letters})
+}
+
+// ReplayDLQHandler moves dead-lettered email jobs back onto the main queue
+func ReplayDLQHandler(c *gin.Context) {
+	limit := parseLimit(c)
+
+	replayed, err := queue.ReplayDeadLetters(limit)
+	if err != nil {
+		logger.Error("Failed to replay DLQ: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay dead-letter queue", "replayed": replayed})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+// CapturedEmailsHandler lists messages recorded by the memory transport, optionally ?to=
+func CapturedEmailsHandler(c *gin.Context) {
+	if !memoryTransportEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory transport is not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": mailer.Captured.Messages(c.Query("to"))})
+}
+
+// ResetCapturedEmailsHandler clears the memory transport
+func ResetCapturedEmailsHandler(c *gin.Context) {
+	if !memoryTransportEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory transport is not enabled"})
+		return
+	}
+	mailer.Captured.Reset()
+	c.Status(http.StatusNoContent)
+}
+
+func memoryTransportEnabled() bool {
+	for _, name := range config.AppConfig.MailTransports {
+		if name == mailer.TransportMemory {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLimit reads ?limit=, clamped to [1, maxDLQLimit]
+func parseLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDLQLimit)))
+	if err != nil || limit < 1 {
+		return defaultDLQLimit
+	}
+	if limit > maxDLQLimit {
+		return maxDLQLimit
+	}
+	return limit
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"email-service/internal/queue"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadyzHandler reports whether the service can accept email jobs
+func ReadyzHandler(c *gin.Context) {
+	if !queue.IsConnected() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "rabbitmq": "disconnected"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "rabbitmq": "connected"})
+}
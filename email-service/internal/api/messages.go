@@ -0,0 +1,47 @@
+package api
+
+import (
+	"email-service/internal/logger"
+	"email-service/internal/tracking"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMessageHandler returns a message's current state and the transitions that led to it
+func GetMessageHandler(c *gin.Context) {
+	msg, err := tracking.Get(c.Param("id"))
+	if errors.Is(err, tracking.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to load message: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load message"})
+		return
+	}
+
+	c.JSON(http.StatusOK, msg)
+}
+
+// CancelMessageHandler cancels a scheduled message that has not been sent yet
+func CancelMessageHandler(c *gin.Context) {
+	id := c.Param("id")
+	err := tracking.Cancel(id)
+	switch {
+	case errors.Is(err, tracking.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	case errors.Is(err, tracking.ErrNotScheduled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Only scheduled messages can be cancelled"})
+		return
+	case err != nil:
+		logger.Error("Failed to cancel message: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel message"})
+		return
+	}
+
+	logger.Info("Cancelled scheduled message %s", id)
+	c.JSON(http.StatusOK, gin.H{"message": "Message cancelled", "message_id": id})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/mailer"
+	"email-service/internal/models"
+	"email-service/internal/queue"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testSubjectPrefix marks test sends so they are not mistaken for real notifications
+const testSubjectPrefix = "[Test] "
+
+// ListTemplatesHandler lists the registered templates, their data keys and locales
+func ListTemplatesHandler(c *gin.Context) {
+	templates := make([]gin.H, 0)
+	for _, id := range mailer.TemplateIDs() {
+		sample, _ := mailer.SampleData(id, nil)
+		templates = append(templates, gin.H{"id": id, "sample": sample, "locales": mailer.Locales(id)})
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "default_locale": config.AppConfig.DefaultLocale})
+}
+
+// PreviewTemplateHandler renders template :id with its sample data, overridden by
+// ?data= (a JSON object), in ?locale=. ?format= selects html (default), text or json.
+func PreviewTemplateHandler(c *gin.Context) {
+	var overrides map[string]interface{}
+	if raw := c.Query("data"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "data must be a JSON object"})
+			return
+		}
+	}
+
+	rendered, ok := renderPreview(c, c.Param("id"), c.Query("locale"), overrides)
+	if !ok {
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTMLBody))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(rendered.TextBody))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"subject": rendered.Subject,
+			"locale":  rendered.Locale,
+			"html":    rendered.HTMLBody,
+			"text":    rendered.TextBody,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html, text or json"})
+	}
+}
+
+// TestSendTemplateHandler renders template :id like the preview and queues it to an
+// address on TEST_EMAIL_ALLOWLIST, with the subject marked as a test
+func TestSendTemplateHandler(c *gin.Context) {
+	var req models.TestSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ensure valid email"})
+		return
+	}
+	if !isTestRecipientAllowed(req.Email) {
+		logger.Info("Rejected test send to non-allowlisted address")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Recipient is not on the test allowlist"})
+		return
+	}
+
+	rendered, ok := renderPreview(c, c.Param("id"), req.Locale, req.Data)
+	if !ok {
+		return
+	}
+
+	if rejectSuppressed(c, req.Email) {
+		return
+	}
+
+	job := models.EmailJob{
+		ID:       queue.NewJobID(""),
+		To:       req.Email,
+		Subject:  testSubjectPrefix + rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Locale:   rendered.Locale,
+	}
+	if !queueJob(c, job, c.Param("id")) {
+		return
+	}
+
+	logger.SecureInfo("Test email %s queued for: %s", c.Param("id"), req.Email)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Test email queued successfully", "message_id": job.ID, "locale": rendered.Locale})
+}
+
+// renderPreview renders template id with sample data and overrides, answering
+// the request itself when that fails
+func renderPreview(c *gin.Context, id, locale string, overrides map[string]interface{}) (*mailer.Rendered, bool) {
+	data, err := mailer.SampleData(id, overrides)
+	if errors.Is(err, mailer.ErrUnknownTemplate) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown template", "templates": mailer.TemplateIDs()})
+		return nil, false
+	}
+	if missing, _ := mailer.MissingFields(id, data); len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing template data", "missing": missing})
+		return nil, false
+	}
+
+	rendered, err := mailer.Render(id, locale, data)
+	if err != nil {
+		logger.Error("Template error: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to render email", "detail": err.Error()})
+		return nil, false
+	}
+	return rendered, true
+}
+
+// isTestRecipientAllowed reports whether addr, or its domain, is on TEST_EMAIL_ALLOWLIST
+func isTestRecipientAllowed(addr string) bool {
+	addr = strings.ToLower(addr)
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return false
+	}
+	for _, entry := range config.AppConfig.TestEmailAllowlist {
+		if entry == addr || (strings.HasPrefix(entry, "@") && entry == addr[at:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,13 +3,75 @@ package api
 import (
 	"email-service/internal/logger"
 	"email-service/internal/mailer"
+	"email-service/internal/middleware"
 	"email-service/internal/models"
+	"email-service/internal/outbox"
 	"email-service/internal/queue"
+	"email-service/internal/suppression"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"net/mail"
+	"time"
 )
 
+// rejectSuppressed answers 422 when recipient is on the suppression list, so the
+// caller can fall back to another channel. Lookup errors let the email through.
+func rejectSuppressed(c *gin.Context, recipient string) bool {
+	s, err := suppression.Find(recipient)
+	if err != nil {
+		logger.Error("Failed to check suppression list: %v", err)
+		return false
+	}
+	if s == nil {
+		return false
+	}
+
+	logger.SecureInfo("Rejected email to suppressed address: %s", recipient)
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Recipient is suppressed", "reason": s.Reason})
+	return true
+}
+
+// queueJob records job as accepted and puts it in the outbox for the relay to
+// publish, answering 500 itself when that fails. The job is durable once the
+// outbox row commits, so callers answer 202 Accepted with the job's ID, the
+// message ID callers track. A send never waits for a broker confirm; this
+// replaces the 503 /send-otp gave when RabbitMQ did not confirm a publish.
+func queueJob(c *gin.Context, job models.EmailJob, template string) bool {
+	if err := outbox.Submit(job, template); err != nil {
+		logger.Error("Failed to queue email job: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue email"})
+		return false
+	}
+	return true
+}
+
+// maxScheduleAhead bounds send_at, so a typo cannot park a job for years
+const maxScheduleAhead = 30 * 24 * time.Hour
+
+// bulkTemplates go to the bulk queue unless the request asks for high priority
+var bulkTemplates = map[string]bool{
+	mailer.TemplateWelcome:          true,
+	mailer.TemplateRecoveryCodesLow: true,
+}
+
+// priorityFor returns the requested priority, else the template's default
+func priorityFor(template, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if bulkTemplates[template] {
+		return models.PriorityBulk
+	}
+	return models.PriorityHigh
+}
+
+// jobIDFor derives the job ID from the request's Idempotency-Key, so a retried
+// submission that slips past the key check is still dropped by the consumer
+func jobIDFor(c *gin.Context) string {
+	return queue.NewJobID(c.GetString(middleware.IdempotencyKeyContext))
+}
+
 // isEmailValid returns true if addr is a syntactically valid email.
 func isEmailValid(addr string) bool {
     _, err := mail.ParseAddress(addr)
@@ -32,31 +94,184 @@ func SendOTPHandler(c *gin.Context) {
     }
 
 	// Log email attempt
-	logger.LogEmailAudit(req.Email, "attempted")
+	if rejectSuppressed(c, req.Email) {
+		return
+	}
 
-	// Render HTML with provided OTP
-	htmlBody, err := mailer.ParseOTPTemplate(req.OTP)
+	// Render HTML and text with provided OTP
+	rendered, err := mailer.ParseOTPTemplate(req.OTP, req.Locale)
 	if err != nil {
 		logger.Error("Template error: %v", err)
-		logger.LogEmailAudit(req.Email, "failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email"})
 		return
 	}
 
 	// Prepare and publish job
 	job := models.EmailJob{
+		ID:       jobIDFor(c),
 		To:       req.Email,
-		Subject:  "Your One-Time Password (OTP)",
-		HTMLBody: htmlBody,
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Locale:   rendered.Locale,
+		Priority: models.PriorityHigh,
 	}
-	if err := queue.PublishEmailJob(job); err != nil {
-		logger.Error("Failed to queue email job: %v", err)
-		logger.LogEmailAudit(req.Email, "failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue email"})
+	if !queueJob(c, job, mailer.TemplateOTP) {
 		return
 	}
 
 	logger.SecureInfo("OTP email job queued for: %s", req.Email)
-	logger.LogEmailAudit(req.Email, "queued")
-	c.JSON(http.StatusOK, gin.H{"message": "OTP email queued successfully"})
+	c.JSON(http.StatusAccepted, gin.H{"message": "OTP email queued successfully", "message_id": job.ID})
+}
+
+func SendMagicLinkHandler(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ensure valid email and link"})
+		logger.Error("Invalid magic link request: %v", err)
+		return
+	}
+
+	if !isEmailValid(req.Email) {
+		logger.Error("Invalid email format: %s", req.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+
+	if rejectSuppressed(c, req.Email) {
+		return
+	}
+
+	rendered, err := mailer.ParseMagicLinkTemplate(req.Link, req.Locale)
+	if err != nil {
+		logger.Error("Template error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email"})
+		return
+	}
+
+	job := models.EmailJob{
+		ID:       jobIDFor(c),
+		To:       req.Email,
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Locale:   rendered.Locale,
+		Priority: models.PriorityHigh,
+	}
+	if !queueJob(c, job, mailer.TemplateMagicLink) {
+		return
+	}
+
+	logger.SecureInfo("Magic link email job queued for: %s", req.Email)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Magic link email queued successfully", "message_id": job.ID})
+}
+
+func SendRecoveryCodesLowHandler(c *gin.Context) {
+	var req models.RecoveryCodesLowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ensure valid email and remaining count"})
+		logger.Error("Invalid recovery codes notice request: %v", err)
+		return
+	}
+
+	if !isEmailValid(req.Email) {
+		logger.Error("Invalid email format: %s", req.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+
+	if rejectSuppressed(c, req.Email) {
+		return
+	}
+
+	rendered, err := mailer.ParseRecoveryCodesLowTemplate(req.Remaining, req.Locale)
+	if err != nil {
+		logger.Error("Template error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email"})
+		return
+	}
+
+	job := models.EmailJob{
+		ID:       jobIDFor(c),
+		To:       req.Email,
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Locale:   rendered.Locale,
+		Priority: priorityFor(mailer.TemplateRecoveryCodesLow, ""),
+	}
+	if !queueJob(c, job, mailer.TemplateRecoveryCodesLow) {
+		return
+	}
+
+	logger.SecureInfo("Recovery codes notice queued for: %s", req.Email)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Recovery codes notice queued successfully", "message_id": job.ID})
+}
+
+// SendTemplateHandler renders any registered template with the given data and queues it
+func SendTemplateHandler(c *gin.Context) {
+	var req models.SendTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ensure valid email and template"})
+		logger.Error("Invalid send request: %v", err)
+		return
+	}
+
+	if !isEmailValid(req.Email) {
+		logger.Error("Invalid email format: %s", req.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+
+	missing, err := mailer.MissingFields(req.Template, req.Data)
+	if errors.Is(err, mailer.ErrUnknownTemplate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown template", "templates": mailer.TemplateIDs()})
+		return
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing template data", "missing": missing})
+		return
+	}
+
+	if req.SendAt != nil {
+		if time.Until(*req.SendAt) > maxScheduleAhead {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "send_at is too far ahead", "max": maxScheduleAhead.String()})
+			return
+		}
+		if !req.SendAt.After(time.Now()) {
+			req.SendAt = nil
+		}
+	}
+
+	if rejectSuppressed(c, req.Email) {
+		return
+	}
+
+	rendered, err := mailer.Render(req.Template, req.Locale, req.Data)
+	if err != nil {
+		logger.Error("Template error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email"})
+		return
+	}
+
+	job := models.EmailJob{
+		ID:       jobIDFor(c),
+		To:       req.Email,
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Locale:   rendered.Locale,
+		Priority: priorityFor(req.Template, req.Priority),
+		SendAt:   req.SendAt,
+	}
+	if !queueJob(c, job, req.Template) {
+		return
+	}
+
+	logger.SecureInfo("%s email job queued for: %s", req.Template, req.Email)
+	if job.SendAt != nil {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Email scheduled successfully", "message_id": job.ID, "send_at": job.SendAt})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Email queued successfully", "message_id": job.ID})
 }
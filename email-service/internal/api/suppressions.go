@@ -0,0 +1,127 @@
+package api
+
+import (
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"email-service/internal/suppression"
+	"email-service/internal/tracking"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSuppressionsHandler lists suppressed addresses, newest first, optionally ?email=
+func ListSuppressionsHandler(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	list, total, err := suppression.List(c.Query("email"), parseLimit(c), offset)
+	if err != nil {
+		logger.Error("Failed to list suppressions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list suppressions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": total, "suppressions": list})
+}
+
+// AddSuppressionHandler suppresses an address by hand
+func AddSuppressionHandler(c *gin.Context) {
+	var req models.SuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: ensure valid email and reason"})
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = models.SuppressionManual
+	}
+
+	s, err := suppression.Add(req.Email, req.Reason, models.SuppressionSourceAdmin, req.Detail, req.ExpiresAt)
+	if err != nil {
+		logger.Error("Failed to add suppression: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add suppression"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, s)
+}
+
+// RemoveSuppressionHandler lifts the suppression of :email
+func RemoveSuppressionHandler(c *gin.Context) {
+	removed, err := suppression.Remove(c.Param("email"))
+	if err != nil {
+		logger.Error("Failed to remove suppression: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove suppression"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address is not suppressed"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// BounceWebhookHandler records provider bounce and complaint notifications. It
+// accepts a single event or an array of them. Hard bounces and complaints
+// suppress the address; soft bounces and other events are ignored.
+func BounceWebhookHandler(c *gin.Context) {
+	raw, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	var events []models.BounceEvent
+	if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(raw, &events)
+	} else {
+		var event models.BounceEvent
+		err = json.Unmarshal(raw, &event)
+		events = append(events, event)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bounce notification"})
+		return
+	}
+
+	suppressed := 0
+	for _, event := range events {
+		reason := bounceReason(event)
+		if reason == "" || !isEmailValid(event.Email) {
+			continue
+		}
+		if _, err := suppression.Add(event.Email, reason, models.SuppressionSourceWebhook, event.Reason, nil); err != nil {
+			logger.Error("Failed to record bounce notification: %v", err)
+			// Let the provider redeliver the batch
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record notification"})
+			return
+		}
+		if reason == models.SuppressionHardBounce && event.MessageID != "" {
+			tracking.Record(event.MessageID, models.MessageBounced, tracking.Change{Reason: "provider reported bounce", Response: event.Reason})
+		}
+		suppressed++
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": len(events), "suppressed": suppressed})
+}
+
+// bounceReason maps a provider event to a suppression reason, or "" to ignore it
+func bounceReason(event models.BounceEvent) string {
+	switch strings.ToLower(event.Event) {
+	case "complaint", "spamreport":
+		return models.SuppressionComplaint
+	case "bounce":
+		switch strings.ToLower(event.Type) {
+		case "transient", "soft", "blocked":
+			return ""
+		}
+		return models.SuppressionHardBounce
+	}
+	return ""
+}
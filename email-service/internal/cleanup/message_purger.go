@@ -0,0 +1,42 @@
+// internal/cleanup/message_purger.go
+package cleanup
+
+import (
+	"time"
+
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/outbox"
+	"email-service/internal/tracking"
+
+	"github.com/robfig/cron/v3"
+)
+
+// StartMessagePurger kicks off a daily job (at 2 AM) that deletes messages
+// that finished more than MESSAGE_RETENTION ago, along with their events, and
+// outbox entries published as long ago. Messages still in flight are never purged.
+func StartMessagePurger() {
+	c := cron.New()
+	// cron spec: minute hour day-of-month month day-of-week
+	_, err := c.AddFunc("0 2 * * *", func() {
+		cutoff := time.Now().Add(-config.AppConfig.MessageRetention)
+
+		purged, err := tracking.Purge(cutoff)
+		if err != nil {
+			logger.Error("[MessagePurger] purge failed: %v", err)
+			return
+		}
+		logger.Info("[MessagePurger] purged %d finished messages", purged)
+
+		relayed, err := outbox.Purge(cutoff)
+		if err != nil {
+			logger.Error("[MessagePurger] outbox purge failed: %v", err)
+			return
+		}
+		logger.Info("[MessagePurger] purged %d published outbox entries", relayed)
+	})
+	if err != nil {
+		logger.Error("failed to schedule message purger: %v", err)
+	}
+	c.Start()
+}
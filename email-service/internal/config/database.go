@@ -25,8 +25,8 @@ func InitDatabase() error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto migrate the audit table
-	if err := DB.AutoMigrate(&models.EmailAudit{}); err != nil {
+	// Auto migrate the message lifecycle and suppression tables
+	if err := DB.AutoMigrate(&models.EmailMessage{}, &models.MessageEvent{}, &models.OutboxEntry{}, &models.Suppression{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
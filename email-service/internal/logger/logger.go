@@ -3,10 +3,6 @@ package logger
 import (
 	"log"
 	"os"
-	"time"
-
-	"email-service/internal/config"
-	"email-service/internal/models"
 )
 
 var (
@@ -46,20 +42,3 @@ func SecureInfo(msg string, args ...interface{}) {
 		Info(msg, args...)
 	} 
 }
-
-// LogEmailAudit logs email metadata to PostgreSQL for traceability and analytics
-func LogEmailAudit(recipient, status string) {
-	audit := models.EmailAudit{
-		From:   config.AppConfig.SMTPUsername,
-		Recipient: recipient,
-		Status:    status,
-		Timestamp: time.Now(),
-	}
-
-	if err := config.DB.Create(&audit).Error; err != nil {
-		Error("Failed to log email audit: %v", err)
-		return
-	}
-
-	Info("Email audit logged: ID=%s, Recipient=%s, Status=%s", config.AppConfig.SMTPUsername, recipient, status)
-}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/go-mail/mail"
+)
+
+// PermanentError is a failure retrying will not fix because the recipient
+// itself was rejected, e.g. an SMTP 550 "no such user" reply
+type PermanentError struct {
+	Code   int
+	Reason string
+}
+
+func (e *PermanentError) Error() string {
+	return fmt.Sprintf("recipient rejected permanently (%d): %s", e.Code, e.Reason)
+}
+
+// IsPermanent reports whether err means the recipient can never be reached,
+// returning the classified error when it does
+func IsPermanent(err error) (*PermanentError, bool) {
+	var perm *PermanentError
+	if errors.As(err, &perm) {
+		return perm, true
+	}
+	return nil, false
+}
+
+// classifySMTPError turns SMTP replies that reject the recipient into a
+// PermanentError. Other 5xx replies (authentication, policy, message size)
+// concern the sender or the message, so they are left to the retry path.
+func classifySMTPError(err error) error {
+	cause := err
+	var sendErr *mail.SendError
+	if errors.As(err, &sendErr) {
+		cause = sendErr.Cause
+	}
+
+	var reply *textproto.Error
+	if !errors.As(cause, &reply) {
+		return err
+	}
+
+	switch {
+	case reply.Code == 550 || reply.Code == 551 || reply.Code == 553:
+	case reply.Code >= 500 && strings.HasPrefix(reply.Msg, "5.1."): // enhanced status: bad destination mailbox
+	default:
+		return err
+	}
+	return &PermanentError{Code: reply.Code, Reason: reply.Msg}
+}
@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// dkimHeaderKeys are the signed header fields (RFC 6376 section 5.4.1)
+var dkimHeaderKeys = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}
+
+// DKIMKey is a loaded signing key for one domain and selector
+type DKIMKey struct {
+	Domain   string
+	Selector string
+	Signer   crypto.Signer
+}
+
+// dkimKeys holds the configured keys by domain; a domain may have several,
+// e.g. RSA alongside Ed25519 since not every verifier supports Ed25519
+var dkimKeys map[string][]DKIMKey
+
+// InitDKIM loads the private keys listed in DKIM_KEYS. Without any, mail is sent unsigned.
+func InitDKIM() error {
+	loaded := make(map[string][]DKIMKey)
+	for _, cfg := range config.AppConfig.DKIMKeys {
+		signer, err := loadDKIMKey(cfg.KeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load DKIM key %s for %s: %w", cfg.Selector, cfg.Domain, err)
+		}
+		loaded[cfg.Domain] = append(loaded[cfg.Domain], DKIMKey{Domain: cfg.Domain, Selector: cfg.Selector, Signer: signer})
+		logger.Info("DKIM signing enabled for %s (selector %s, %s)", cfg.Domain, cfg.Selector, keyAlgorithm(signer))
+	}
+	dkimKeys = loaded
+	return nil
+}
+
+// loadDKIMKey reads a PEM private key: PKCS#1 RSA, or PKCS#8 RSA or Ed25519
+func loadDKIMKey(path string) (crypto.Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		}
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// DNSRecord returns the TXT record value to publish at <selector>._domainkey.<domain>
+func (k DKIMKey) DNSRecord() (string, error) {
+	var pub []byte
+	switch p := k.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(p)
+		if err != nil {
+			return "", err
+		}
+		pub = der
+	case ed25519.PublicKey:
+		pub = p
+	default:
+		return "", fmt.Errorf("unsupported key type %T", p)
+	}
+	return fmt.Sprintf("v=DKIM1; k=%s; p=%s", keyAlgorithm(k.Signer), base64.StdEncoding.EncodeToString(pub)), nil
+}
+
+func keyAlgorithm(signer crypto.Signer) string {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return "ed25519"
+	}
+	return "rsa"
+}
+
+// EncodeSigned serializes msg and signs it with keys; no keys leaves it unsigned
+func EncodeSigned(msg Message, keys []DKIMKey) (rawMessage, error) {
+	var buf bytes.Buffer
+	if _, err := toMIME(msg).WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return SignDKIM(buf.Bytes(), keys)
+}
+
+// senderKeys returns the keys of the domain mail from fromAddress is signed for
+func senderKeys(fromAddress string) []DKIMKey {
+	at := strings.LastIndex(fromAddress, "@")
+	if at < 0 {
+		return nil
+	}
+	return dkimKeys[strings.ToLower(fromAddress[at+1:])]
+}
+
+// SignDKIM prepends a DKIM-Signature header to raw for each key, the last key's
+// signature ending up outermost. Relaxed canonicalization survives the header
+// rewrapping some relays do.
+func SignDKIM(raw []byte, keys []DKIMKey) ([]byte, error) {
+	for _, key := range keys {
+		var signed bytes.Buffer
+		err := dkim.Sign(&signed, bytes.NewReader(raw), &dkim.SignOptions{
+			Domain:                 key.Domain,
+			Selector:               key.Selector,
+			Signer:                 key.Signer,
+			Hash:                   crypto.SHA256,
+			HeaderCanonicalization: dkim.CanonicalizationRelaxed,
+			BodyCanonicalization:   dkim.CanonicalizationRelaxed,
+			HeaderKeys:             dkimHeaderKeys,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("DKIM signing for %s failed: %w", key.Domain, err)
+		}
+		raw = signed.Bytes()
+	}
+	return raw, nil
+}
+
+// rawMessage lets an already serialized message go through go-mail senders
+type rawMessage []byte
+
+func (r rawMessage) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(r)
+	return int64(n), err
+}
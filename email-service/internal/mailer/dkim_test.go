@@ -0,0 +1,255 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"email-service/internal/config"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// rsaTestKey is generated once; RSA-2048 generation is slow enough to matter per test
+var rsaTestKey *rsa.PrivateKey
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	if rsaTestKey == nil {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("generate RSA key: %v", err)
+		}
+		rsaTestKey = key
+	}
+	return rsaTestKey
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	return key
+}
+
+// txtLookup serves the DNS records of keys, standing in for a real resolver
+func txtLookup(t *testing.T, keys ...DKIMKey) func(string) ([]string, error) {
+	t.Helper()
+	records := map[string][]string{}
+	for _, key := range keys {
+		record, err := key.DNSRecord()
+		if err != nil {
+			t.Fatalf("DNSRecord for %s: %v", key.Selector, err)
+		}
+		name := key.Selector + "._domainkey." + key.Domain
+		records[name] = append(records[name], record)
+	}
+	return func(domain string) ([]string, error) {
+		if txt, ok := records[domain]; ok {
+			return txt, nil
+		}
+		return nil, fmt.Errorf("no TXT record for %s", domain)
+	}
+}
+
+func sampleMessage(domain string) Message {
+	return Message{
+		FromName:    "DKIM test",
+		FromAddress: "no-reply@" + domain,
+		To:          "check@example.net",
+		Subject:     "DKIM test",
+		HTMLBody:    "<p>Signed sample</p>",
+		TextBody:    "Signed sample",
+	}
+}
+
+func verify(t *testing.T, raw []byte, lookup func(string) ([]string, error)) []*dkim.Verification {
+	t.Helper()
+	verifications, err := dkim.VerifyWithOptions(bytes.NewReader(raw), &dkim.VerifyOptions{LookupTXT: lookup})
+	if err != nil {
+		t.Fatalf("VerifyWithOptions: %v", err)
+	}
+	return verifications
+}
+
+func TestSignDKIMVerifies(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       DKIMKey
+		algorithm string
+	}{
+		{"rsa-sha256", DKIMKey{Domain: "example.com", Selector: "rsa2024", Signer: testRSAKey(t)}, "rsa"},
+		{"ed25519-sha256", DKIMKey{Domain: "example.com", Selector: "ed2024", Signer: testEd25519Key(t)}, "ed25519"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := EncodeSigned(sampleMessage("example.com"), []DKIMKey{tt.key})
+			if err != nil {
+				t.Fatalf("EncodeSigned: %v", err)
+			}
+			if !bytes.Contains(signed, []byte("a="+tt.name)) {
+				t.Errorf("signature does not use a=%s", tt.name)
+			}
+			if record, _ := tt.key.DNSRecord(); !strings.HasPrefix(record, "v=DKIM1; k="+tt.algorithm+"; p=") {
+				t.Errorf("DNS record = %q, want k=%s", record, tt.algorithm)
+			}
+
+			verifications := verify(t, signed, txtLookup(t, tt.key))
+			if len(verifications) != 1 {
+				t.Fatalf("found %d signatures, want 1", len(verifications))
+			}
+			if err := verifications[0].Err; err != nil {
+				t.Fatalf("signature did not verify: %v", err)
+			}
+			if verifications[0].Domain != "example.com" {
+				t.Errorf("signing domain = %q, want example.com", verifications[0].Domain)
+			}
+		})
+	}
+}
+
+func TestSignDKIMTamperedHeaderFails(t *testing.T) {
+	keys := []DKIMKey{
+		{Domain: "example.com", Selector: "rsa2024", Signer: testRSAKey(t)},
+		{Domain: "example.com", Selector: "ed2024", Signer: testEd25519Key(t)},
+	}
+	signed, err := EncodeSigned(sampleMessage("example.com"), keys)
+	if err != nil {
+		t.Fatalf("EncodeSigned: %v", err)
+	}
+	lookup := txtLookup(t, keys...)
+
+	tampered := []struct {
+		name     string
+		old, new string
+	}{
+		{"subject", "Subject: DKIM test", "Subject: DKIM tesT"},
+		{"recipient", "To: check@example.net", "To: other@example.net"},
+		{"body", "Signed sample", "Signed simple"},
+	}
+	for _, tt := range tampered {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := bytes.Replace(signed, []byte(tt.old), []byte(tt.new), 1)
+			if bytes.Equal(raw, signed) {
+				t.Fatalf("%q not found in the signed message", tt.old)
+			}
+
+			verifications := verify(t, raw, lookup)
+			if len(verifications) != len(keys) {
+				t.Fatalf("found %d signatures, want %d", len(verifications), len(keys))
+			}
+			for _, v := range verifications {
+				if v.Err == nil {
+					t.Errorf("tampered message still verified for %s", v.Domain)
+				}
+			}
+		})
+	}
+}
+
+func TestSignDKIMWithoutKeysLeavesMessageUnsigned(t *testing.T) {
+	signed, err := EncodeSigned(sampleMessage("example.com"), nil)
+	if err != nil {
+		t.Fatalf("EncodeSigned: %v", err)
+	}
+	if bytes.Contains(signed, []byte("DKIM-Signature")) {
+		t.Error("message signed without keys")
+	}
+}
+
+// writeKey stores key as a PKCS#8 PEM file like the ones DKIM_KEYS points to
+func writeKey(t *testing.T, dir, name string, key interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestDKIMMultipleDomains(t *testing.T) {
+	dir := t.TempDir()
+	rsaPath := writeKey(t, dir, "rsa.pem", testRSAKey(t))
+	edPath := writeKey(t, dir, "ed25519.pem", testEd25519Key(t))
+	otherPath := writeKey(t, dir, "other.pem", testEd25519Key(t))
+
+	saved := *config.AppConfig
+	t.Cleanup(func() {
+		*config.AppConfig = saved
+		dkimKeys = nil
+	})
+	config.AppConfig.DKIMKeys = []config.DKIMKey{
+		{Domain: "example.com", Selector: "rsa2024", KeyPath: rsaPath},
+		{Domain: "example.com", Selector: "ed2024", KeyPath: edPath},
+		{Domain: "example.org", Selector: "org2024", KeyPath: otherPath},
+	}
+	if err := InitDKIM(); err != nil {
+		t.Fatalf("InitDKIM: %v", err)
+	}
+
+	var all []DKIMKey
+	for _, domain := range []string{"example.com", "example.org"} {
+		all = append(all, dkimKeys[domain]...)
+	}
+	lookup := txtLookup(t, all...)
+
+	tests := []struct {
+		from      string
+		selectors []string
+	}{
+		{"no-reply@example.com", []string{"rsa2024", "ed2024"}},
+		{"no-reply@Example.ORG", []string{"org2024"}},
+		{"no-reply@example.net", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			msg := sampleMessage("example.com")
+			msg.FromAddress = tt.from
+			signed, err := encodeMessage(msg)
+			if err != nil {
+				t.Fatalf("encodeMessage: %v", err)
+			}
+
+			verifications := verify(t, signed, lookup)
+			if len(verifications) != len(tt.selectors) {
+				t.Fatalf("found %d signatures, want %d", len(verifications), len(tt.selectors))
+			}
+			for _, v := range verifications {
+				if v.Err != nil {
+					t.Errorf("signature for %s did not verify: %v", v.Domain, v.Err)
+				}
+				if want := strings.ToLower(tt.from[strings.LastIndex(tt.from, "@")+1:]); v.Domain != want {
+					t.Errorf("signed for %s, want %s", v.Domain, want)
+				}
+			}
+			for _, selector := range tt.selectors {
+				if !bytes.Contains(signed, []byte("s="+selector)) {
+					t.Errorf("no signature with selector %s", selector)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDKIMKeyRejectsUnknownPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0}}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDKIMKey(path); err == nil {
+		t.Error("loadDKIMKey accepted a certificate")
+	}
+}
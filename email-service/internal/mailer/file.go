@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTransport writes each message as an .eml file into a Maildir
+// (dir/tmp, dir/new, dir/cur), so any mail client can open the output
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) (*FileTransport, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create maildir %s: %w", dir, err)
+		}
+	}
+	return &FileTransport{dir: dir}, nil
+}
+
+func (t *FileTransport) Name() string { return TransportFile }
+
+// Send writes to tmp/ and renames into new/, so readers never see a partial file.
+// It returns the delivered file's path.
+func (t *FileTransport) Send(ctx context.Context, msg Message) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), hex.EncodeToString(suffix))
+	tmpPath := filepath.Join(t.dir, "tmp", name)
+
+	raw, err := encodeMessage(msg)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := raw.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	newPath := filepath.Join(t.dir, "new", name)
+	if err := os.Rename(tmpPath, newPath); err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+var _ Transport = (*FileTransport)(nil)
@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP API payload formats
+const (
+	HTTPFormatGeneric  = "generic"  // {"from","from_name","to","subject","html","text"}
+	HTTPFormatSendGrid = "sendgrid" // SendGrid v3 /mail/send
+)
+
+// HTTPTransport posts messages as JSON to a provider API, authenticated with a bearer key
+type HTTPTransport struct {
+	url    string
+	apiKey string
+	format string
+	client *http.Client
+}
+
+func NewHTTPTransport(url, apiKey, format string) *HTTPTransport {
+	return &HTTPTransport{
+		url:    url,
+		apiKey: apiKey,
+		format: format,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *HTTPTransport) Name() string { return TransportHTTP }
+
+// Send posts msg to the provider and returns its status with the provider's
+// message ID when it gives one (X-Message-Id), else the start of its reply
+func (t *HTTPTransport) Send(ctx context.Context, msg Message) (string, error) {
+	body, err := json.Marshal(t.payload(msg))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("provider returned status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	if id := resp.Header.Get("X-Message-Id"); id != "" {
+		return fmt.Sprintf("%d %s", resp.StatusCode, id), nil
+	}
+	return strings.TrimSpace(fmt.Sprintf("%d %s", resp.StatusCode, bytes.TrimSpace(detail))), nil
+}
+
+func (t *HTTPTransport) payload(msg Message) interface{} {
+	if t.format == HTTPFormatSendGrid {
+		type address struct {
+			Email string `json:"email"`
+			Name  string `json:"name,omitempty"`
+		}
+		type content struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		}
+		// SendGrid requires text/plain to come before text/html
+		contents := []content{}
+		if msg.TextBody != "" {
+			contents = append(contents, content{Type: "text/plain", Value: msg.TextBody})
+		}
+		contents = append(contents, content{Type: "text/html", Value: msg.HTMLBody})
+		return map[string]interface{}{
+			"personalizations": []map[string]interface{}{{"to": []address{{Email: msg.To}}}},
+			"from":             address{Email: msg.FromAddress, Name: msg.FromName},
+			"subject":          msg.Subject,
+			"content":          contents,
+		}
+	}
+
+	return map[string]string{
+		"from":      msg.FromAddress,
+		"from_name": msg.FromName,
+		"to":        msg.To,
+		"subject":   msg.Subject,
+		"html":      msg.HTMLBody,
+		"text":      msg.TextBody,
+	}
+}
+
+var _ Transport = (*HTTPTransport)(nil)
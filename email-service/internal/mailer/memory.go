@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CapturedMessage is a message recorded by the memory transport
+type CapturedMessage struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+// MemoryTransport keeps sent messages in memory so tests can inspect them
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []CapturedMessage
+}
+
+// Captured is the memory transport used when MAIL_TRANSPORTS includes "memory"
+var Captured = &MemoryTransport{}
+
+func (t *MemoryTransport) Name() string { return TransportMemory }
+
+func (t *MemoryTransport) Send(ctx context.Context, msg Message) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, CapturedMessage{Message: msg, SentAt: time.Now()})
+	return "captured in memory", nil
+}
+
+// Messages returns a copy of the captured messages, optionally only those sent to "to"
+func (t *MemoryTransport) Messages(to string) []CapturedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := []CapturedMessage{}
+	for _, m := range t.messages {
+		if to == "" || m.To == to {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Reset discards all captured messages
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
+
+var _ Transport = (*MemoryTransport)(nil)
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"email-service/internal/config"
+	"sync"
+	"time"
+
+	"github.com/go-mail/mail"
+)
+
+// smtpIdleTimeout drops pooled connections the server has probably closed already
+const smtpIdleTimeout = 30 * time.Second
+
+type pooledConn struct {
+	sender   mail.SendCloser
+	lastUsed time.Time
+}
+
+// smtpPool keeps up to SMTP_POOL_SIZE authenticated SMTP connections for reuse
+type smtpPool struct {
+	dialer *mail.Dialer
+	idle   chan *pooledConn
+}
+
+var (
+	pool     *smtpPool
+	poolOnce sync.Once
+)
+
+func getPool() *smtpPool {
+	poolOnce.Do(func() {
+		cfg := config.AppConfig
+		d := mail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+		d.StartTLSPolicy = mail.MandatoryStartTLS
+		pool = &smtpPool{
+			dialer: d,
+			idle:   make(chan *pooledConn, cfg.SMTPPoolSize),
+		}
+	})
+	return pool
+}
+
+// get returns a live idle connection, or dials a new one
+func (p *smtpPool) get() (*pooledConn, error) {
+	for {
+		select {
+		case conn := <-p.idle:
+			if time.Since(conn.lastUsed) > smtpIdleTimeout {
+				conn.sender.Close()
+				continue
+			}
+			return conn, nil
+		default:
+			sender, err := p.dialer.Dial()
+			if err != nil {
+				return nil, err
+			}
+			return &pooledConn{sender: sender}, nil
+		}
+	}
+}
+
+// put returns a healthy connection to the pool, closing it if the pool is full
+func (p *smtpPool) put(conn *pooledConn) {
+	conn.lastUsed = time.Now()
+	select {
+	case p.idle <- conn:
+	default:
+		conn.sender.Close()
+	}
+}
+
+// ClosePool closes every idle SMTP connection
+func ClosePool() {
+	if pool == nil {
+		return
+	}
+	for {
+		select {
+		case conn := <-pool.idle:
+			conn.sender.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"email-service/internal/config"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*rate.Limiter{}
+)
+
+// waitForProvider blocks until provider may send another message.
+// Providers without a configured limit are not throttled.
+func waitForProvider(ctx context.Context, provider string) error {
+	limiter := limiterFor(provider)
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
+func limiterFor(provider string) *rate.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	if limiter, ok := limiters[provider]; ok {
+		return limiter
+	}
+	limit, ok := config.AppConfig.ProviderRateLimits[provider]
+	if !ok {
+		return nil
+	}
+	limiter := rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+	limiters[provider] = limiter
+	return limiter
+}
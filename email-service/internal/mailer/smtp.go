@@ -1,31 +1,58 @@
 package mailer
 
 import (
-	"email-service/internal/config"
-	"email-service/internal/logger"
-	"email-service/internal/models"
-	"fmt"
-	"github.com/go-mail/mail"
+	"context"
 )
 
-// SendEmail sends an email using SMTP with HTML content.
-func SendEmail(data models.EmailJob) error {
-	cfg := config.AppConfig
+// SMTPTransport sends over pooled, authenticated SMTP connections with mandatory STARTTLS
+type SMTPTransport struct {
+	pool *smtpPool
+}
+
+func NewSMTPTransport() *SMTPTransport {
+	return &SMTPTransport{pool: getPool()}
+}
+
+func (t *SMTPTransport) Name() string { return TransportSMTP }
 
-	m := mail.NewMessage()
-	m.SetHeader("From", fmt.Sprintf("%s <%s>", cfg.SMTPFromName, cfg.SMTPUsername))
-	m.SetHeader("To", data.To)
-	m.SetHeader("Subject", data.Subject)
-	m.SetBody("text/html", data.HTMLBody)
+// Send delivers msg over a pooled connection. A reused connection may have been
+// dropped by the server, so a failure on one is retried once on a fresh dial.
+// Replies rejecting the recipient are returned as a *PermanentError.
+// The SMTP client does not expose the server's reply to DATA, so there is no
+// provider response to return and tracking records none.
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) (string, error) {
+	raw, err := encodeMessage(msg)
+	if err != nil {
+		return "", err
+	}
+	to := []string{msg.To}
+
+	conn, err := t.pool.get()
+	if err != nil {
+		return "", err
+	}
+	reused := !conn.lastUsed.IsZero()
 
-	d := mail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
-	d.StartTLSPolicy = mail.MandatoryStartTLS
+	if err := conn.sender.Send(msg.FromAddress, to, raw); err != nil {
+		conn.sender.Close()
+		err = classifySMTPError(err)
+		if _, permanent := IsPermanent(err); !reused || permanent {
+			return "", err
+		}
 
-	if err := d.DialAndSend(m); err != nil {
-		logger.Error("Failed to send email to %s: %v", data.To, err)
-		return fmt.Errorf("failed to send email to %s: %w", data.To, err)
+		sender, dialErr := t.pool.dialer.Dial()
+		if dialErr != nil {
+			return "", dialErr
+		}
+		conn = &pooledConn{sender: sender}
+		if err := conn.sender.Send(msg.FromAddress, to, raw); err != nil {
+			conn.sender.Close()
+			return "", classifySMTPError(err)
+		}
 	}
 
-	logger.SecureInfo("Email successfully sent to: %s", data.To)
-	return nil
+	t.pool.put(conn)
+	return "", nil
 }
+
+var _ Transport = (*SMTPTransport)(nil)
@@ -3,32 +3,374 @@ package mailer
 import (
 	"bytes"
 	"email-service/internal/config"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-type OTPTemplateData struct {
-	OTP     string
-	AppName string
+// Template IDs accepted by POST /send
+const (
+	TemplateOTP              = "otp"
+	TemplateMagicLink        = "magic_link"
+	TemplateRecoveryCodesLow = "recovery_codes_low"
+	TemplateWelcome          = "welcome"
+	TemplateNewDeviceLogin   = "new_device_login"
+	TemplateLockout          = "lockout"
+	TemplateAccountDeletion  = "account_deletion"
+)
+
+// TemplateSpec describes an email type: its subject and the data it needs
+type TemplateSpec struct {
+	Subject  string
+	Required []string // data keys that must be provided
+	Optional []string // data keys that default to empty
+	// Sample is realistic data for previews, covering every required and optional key
+	Sample map[string]interface{}
+}
+
+var templateSpecs = map[string]TemplateSpec{
+	TemplateOTP: {
+		Subject:  "Your One-Time Password (OTP)",
+		Required: []string{"OTP"},
+		Sample:   map[string]interface{}{"OTP": "482913"},
+	},
+	TemplateMagicLink: {
+		Subject:  "Your sign-in link",
+		Required: []string{"Link"},
+		Sample:   map[string]interface{}{"Link": "https://example.com/verify-link?token=sample-token"},
+	},
+	TemplateRecoveryCodesLow: {
+		Subject:  "You are running low on recovery codes",
+		Required: []string{"Remaining"},
+		Sample:   map[string]interface{}{"Remaining": 2},
+	},
+	TemplateWelcome: {
+		Subject:  "Welcome!",
+		Optional: []string{"Name"},
+		Sample:   map[string]interface{}{"Name": "Alex"},
+	},
+	TemplateNewDeviceLogin: {
+		Subject:  "New sign-in to your account",
+		Required: []string{"Device", "IP", "Time"},
+		Optional: []string{"Location"},
+		Sample:   map[string]interface{}{"Device": "Firefox on Linux", "IP": "203.0.113.7", "Time": "2024-06-01 10:00 UTC", "Location": "Lyon, France"},
+	},
+	TemplateLockout: {
+		Subject:  "Your account has been locked",
+		Required: []string{"Until"},
+		Optional: []string{"Reason"},
+		Sample:   map[string]interface{}{"Until": "2024-06-01 10:15 UTC"},
+	},
+	TemplateAccountDeletion: {
+		Subject:  "Account deletion requested",
+		Optional: []string{"DeletionDate", "CancelLink"},
+		Sample:   map[string]interface{}{"DeletionDate": "2024-06-30", "CancelLink": "https://example.com/account/keep?token=sample-token"},
+	},
+}
+
+// ErrUnknownTemplate is returned for template IDs that are not registered
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Rendered is a ready-to-send email with HTML and plain-text alternatives
+type Rendered struct {
+	Subject  string
+	HTMLBody string
+	TextBody string
+	Locale   string // locale the email was actually rendered in
+}
+
+// defaultStrings are the partial strings of the default locale; catalogs
+// in templates/i18n translate them and the subjects ("subject.<id>")
+var defaultStrings = map[string]string{
+	"paste_link": "Or paste this link into your browser:",
 }
 
-// ParseOTPTemplate loads and renders the HTML template with dynamic data.
-func ParseOTPTemplate(otp string) (string, error) {
-	tmplPath := filepath.Join("templates", "otp_email.html")
-	tmpl, err := template.ParseFiles(tmplPath)
+// registry holds every email template parsed once at startup, keyed by
+// template ID then locale ("" for the default), each combined with the
+// shared layouts and partials
+var registry map[string]map[string]*template.Template
+
+// catalogs holds the translated strings of each locale, keyed by locale
+var catalogs map[string]map[string]string
+
+var templateFuncs = template.FuncMap{
+	// dict builds a map for passing several values to a partial
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, errors.New("dict expects key/value pairs")
+		}
+		m := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, errors.New("dict keys must be strings")
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	},
+	// t is rebound per locale when parsing; this placeholder lets the
+	// shared partials parse before a locale is chosen
+	"t": func(key string) string { return key },
+}
+
+// InitTemplates parses the layouts, partials, translation catalogs and every
+// registered email template under dir. Locale variants are named
+// emails/<id>.<locale>.html, e.g. otp.fr.html or otp.pt-BR.html.
+func InitTemplates(dir string) error {
+	loaded, err := loadCatalogs(filepath.Join(dir, "i18n"))
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	var buf bytes.Buffer
-	data := OTPTemplateData{
-		OTP:     otp,
-		AppName: config.AppConfig.SMTPFromName,
+	parsed := make(map[string]map[string]*template.Template, len(templateSpecs))
+	for id := range templateSpecs {
+		variants := map[string]string{"": filepath.Join(dir, "emails", id+".html")}
+		matches, err := filepath.Glob(filepath.Join(dir, "emails", id+".*.html"))
+		if err != nil {
+			return err
+		}
+		for _, path := range matches {
+			locale := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), id+"."), ".html")
+			if locale = NormalizeLocale(locale); locale != "" {
+				variants[locale] = path
+			}
+		}
+
+		parsed[id] = make(map[string]*template.Template, len(variants))
+		for locale, path := range variants {
+			tmpl, err := parseBase(dir, loaded, locale)
+			if err != nil {
+				return err
+			}
+			if tmpl, err = tmpl.ParseFiles(path); err != nil {
+				return fmt.Errorf("failed to parse template %s: %w", filepath.Base(path), err)
+			}
+			parsed[id][locale] = tmpl
+		}
 	}
 
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
+	catalogs = loaded
+	registry = parsed
+	return nil
+}
+
+// parseBase parses the layouts and partials with t bound to locale
+func parseBase(dir string, loaded map[string]map[string]string, locale string) (*template.Template, error) {
+	funcs := template.FuncMap{"t": func(key string) string { return translate(loaded, locale, key, defaultStrings[key]) }}
+	base, err := template.New("").Funcs(templateFuncs).Funcs(funcs).Option("missingkey=error").ParseGlob(filepath.Join(dir, "layouts", "*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layouts: %w", err)
+	}
+	if base, err = base.ParseGlob(filepath.Join(dir, "partials", "*.html")); err != nil {
+		return nil, fmt.Errorf("failed to parse partials: %w", err)
+	}
+	return base, nil
+}
+
+// loadCatalogs reads every <locale>.json string catalog in dir; a missing
+// directory simply means no translations
+func loadCatalogs(dir string) (map[string]map[string]string, error) {
+	loaded := map[string]map[string]string{}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		strs := map[string]string{}
+		if err := json.Unmarshal(raw, &strs); err != nil {
+			return nil, fmt.Errorf("failed to parse catalog %s: %w", filepath.Base(path), err)
+		}
+		if locale := NormalizeLocale(strings.TrimSuffix(filepath.Base(path), ".json")); locale != "" {
+			loaded[locale] = strs
+		}
+	}
+	return loaded, nil
+}
+
+// translate looks key up for locale, then its language, then returns fallback
+func translate(loaded map[string]map[string]string, locale, key, fallback string) string {
+	for _, candidate := range localeChain(locale) {
+		if s, ok := loaded[candidate][key]; ok && s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+// NormalizeLocale canonicalises a BCP 47 style tag: "pt_br" becomes "pt-BR".
+// Anything that does not look like a locale returns "".
+func NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return ""
+	}
+	for i, part := range parts {
+		if part == "" || len(part) > 8 {
+			return ""
+		}
+		for _, r := range part {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				return ""
+			}
+		}
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part) // region
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:]) // script
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// localeChain lists the lookups for locale from most to least specific,
+// e.g. "zh-Hant-TW" gives "zh-Hant-TW", "zh-Hant", "zh"
+func localeChain(locale string) []string {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		return nil
+	}
+	var chain []string
+	for {
+		chain = append(chain, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			return chain
+		}
+		locale = locale[:i]
 	}
+}
+
+// resolveLocale picks the most specific variant of template id for locale,
+// falling back to its language and then to the default ("")
+func resolveLocale(id, locale string) string {
+	for _, candidate := range localeChain(locale) {
+		if _, ok := registry[id][candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// TemplateIDs lists the registered template IDs in sorted order
+func TemplateIDs() []string {
+	ids := make([]string, 0, len(templateSpecs))
+	for id := range templateSpecs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Locales lists the translated variants of template id in sorted order,
+// not including the default
+func Locales(id string) []string {
+	locales := make([]string, 0, len(registry[id]))
+	for locale := range registry[id] {
+		if locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// SampleData returns a copy of the preview data of template id with
+// overrides applied on top
+func SampleData(id string, overrides map[string]interface{}) (map[string]interface{}, error) {
+	spec, ok := templateSpecs[id]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+	data := make(map[string]interface{}, len(spec.Sample)+len(overrides))
+	for k, v := range spec.Sample {
+		data[k] = v
+	}
+	for k, v := range overrides {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// MissingFields returns the required data keys of template id that data lacks
+func MissingFields(id string, data map[string]interface{}) ([]string, error) {
+	spec, ok := templateSpecs[id]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+	var missing []string
+	for _, key := range spec.Required {
+		if v, ok := data[key]; !ok || v == nil || v == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
+
+// Render executes template id in locale with data and derives its plain-text
+// alternative. A locale without a variant falls back to its language and then
+// to the default templates; the subject is translated into the same locale.
+// AppName and Locale are always provided; optional keys missing from data default to "".
+func Render(id, locale string, data map[string]interface{}) (*Rendered, error) {
+	spec, ok := templateSpecs[id]
+	if !ok || registry[id] == nil {
+		return nil, ErrUnknownTemplate
+	}
+	resolved := resolveLocale(id, locale)
+	tmpl := registry[id][resolved]
+	if resolved == "" {
+		resolved = config.AppConfig.DefaultLocale
+	}
+
+	values := make(map[string]interface{}, len(data)+len(spec.Optional)+1)
+	for _, key := range spec.Optional {
+		values[key] = ""
+	}
+	for k, v := range data {
+		values[k] = v
+	}
+	values["AppName"] = config.AppConfig.SMTPFromName
+	values["Locale"] = resolved
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", values); err != nil {
+		return nil, err
+	}
+
+	html := buf.String()
+	return &Rendered{
+		Subject:  translate(catalogs, resolved, "subject."+id, spec.Subject),
+		HTMLBody: html,
+		TextBody: HTMLToText(html),
+		Locale:   resolved,
+	}, nil
+}
+
+// ParseOTPTemplate renders the OTP email with the given code.
+func ParseOTPTemplate(otp, locale string) (*Rendered, error) {
+	return Render(TemplateOTP, locale, map[string]interface{}{"OTP": otp})
+}
+
+// ParseMagicLinkTemplate renders the magic-link login email with the given link.
+func ParseMagicLinkTemplate(link, locale string) (*Rendered, error) {
+	return Render(TemplateMagicLink, locale, map[string]interface{}{"Link": link})
+}
 
-	return buf.String(), nil
+// ParseRecoveryCodesLowTemplate renders the notice sent when few recovery codes are left.
+func ParseRecoveryCodesLowTemplate(remaining int, locale string) (*Rendered, error) {
+	return Render(TemplateRecoveryCodesLow, locale, map[string]interface{}{"Remaining": remaining})
 }
@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	spaceRuns  = regexp.MustCompile(`[ \t]+`)
+)
+
+// blockElements start on a new line in the text rendering
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "li": true, "tr": true, "table": true,
+}
+
+// HTMLToText derives a text/plain alternative from a rendered HTML email.
+// Links keep their URL in parentheses when it differs from the link text.
+func HTMLToText(doc string) string {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "head", "style", "script", "title":
+				return
+			}
+			// Decorative elements carry no text worth keeping
+			if attr(n, "class") == "logo" {
+				return
+			}
+			if blockElements[n.Data] {
+				b.WriteString("\n")
+			}
+		}
+
+		if n.Type == html.TextNode {
+			// Keep one space where the source had surrounding whitespace
+			if strings.TrimLeft(n.Data, " \t\n") != n.Data {
+				b.WriteString(" ")
+			}
+			b.WriteString(strings.Join(strings.Fields(n.Data), " "))
+			if strings.TrimRight(n.Data, " \t\n") != n.Data {
+				b.WriteString(" ")
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type == html.ElementNode {
+			if n.Data == "a" {
+				href := attr(n, "href")
+				if href != "" && !strings.Contains(textOf(n), href) {
+					b.WriteString(" (" + href + ")")
+				}
+			}
+			if blockElements[n.Data] && n.Data != "br" {
+				b.WriteString("\n")
+			}
+		}
+	}
+	walk(root)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
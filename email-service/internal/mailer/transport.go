@@ -0,0 +1,159 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-mail/mail"
+)
+
+// Transport names accepted in MAIL_TRANSPORTS
+const (
+	TransportSMTP   = "smtp"
+	TransportHTTP   = "http"
+	TransportFile   = "file"
+	TransportMemory = "memory"
+)
+
+// Message is a rendered email ready to hand to a transport
+type Message struct {
+	FromName    string `json:"from_name"`
+	FromAddress string `json:"from_address"`
+	To          string `json:"to"`
+	Subject     string `json:"subject"`
+	HTMLBody    string `json:"html_body"`
+	TextBody    string `json:"text_body"`
+}
+
+// Transport delivers a rendered message through one provider
+type Transport interface {
+	Name() string
+	// Send delivers msg and returns the provider's response, e.g. its message ID,
+	// or "" when the transport has none
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Delivery describes a successful send
+type Delivery struct {
+	Transport string
+	Response  string
+}
+
+// transports are tried in MAIL_TRANSPORTS order until one succeeds
+var transports []Transport
+
+// InitTransports builds the configured transports. It fails on unknown names.
+func InitTransports() error {
+	cfg := config.AppConfig
+	transports = nil
+
+	for _, name := range cfg.MailTransports {
+		switch name {
+		case TransportSMTP:
+			transports = append(transports, NewSMTPTransport())
+		case TransportHTTP:
+			if cfg.MailHTTPURL == "" {
+				return errors.New("MAIL_HTTP_URL is required for the http transport")
+			}
+			transports = append(transports, NewHTTPTransport(cfg.MailHTTPURL, cfg.MailHTTPAPIKey, cfg.MailHTTPFormat))
+		case TransportFile:
+			t, err := NewFileTransport(cfg.MailFileDir)
+			if err != nil {
+				return err
+			}
+			transports = append(transports, t)
+		case TransportMemory:
+			transports = append(transports, Captured)
+		default:
+			return fmt.Errorf("unknown mail transport %q", name)
+		}
+	}
+
+	if len(transports) == 0 {
+		return errors.New("no mail transports configured")
+	}
+	logger.Info("Mail transports: %s", strings.Join(cfg.MailTransports, " -> "))
+	return nil
+}
+
+// SendEmail renders the job and sends it through the first transport that accepts it,
+// failing over to the next configured transport on error. A *PermanentError
+// stops the failover since the recipient itself was rejected.
+func SendEmail(data models.EmailJob) (Delivery, error) {
+	cfg := config.AppConfig
+	msg := Message{
+		FromName:    cfg.SMTPFromName,
+		FromAddress: cfg.MailFromAddress,
+		To:          data.To,
+		Subject:     data.Subject,
+		HTMLBody:    data.HTMLBody,
+		TextBody:    data.TextBody,
+	}
+
+	ctx := context.Background()
+	var lastErr error
+	for _, t := range transports {
+		if err := waitForProvider(ctx, t.Name()); err != nil {
+			return Delivery{}, fmt.Errorf("rate limiter: %w", err)
+		}
+
+		response, err := t.Send(ctx, msg)
+		if err != nil {
+			logger.Error("Transport %s failed to send email to %s: %v", t.Name(), data.To, err)
+			if _, ok := IsPermanent(err); ok {
+				// Another provider would be rejected by the same mailbox
+				return Delivery{Transport: t.Name()}, fmt.Errorf("failed to send email to %s: %w", data.To, err)
+			}
+			lastErr = err
+			continue
+		}
+
+		logger.SecureInfo("Email successfully sent to %s via %s", data.To, t.Name())
+		return Delivery{Transport: t.Name(), Response: response}, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no mail transports configured")
+	}
+	return Delivery{}, fmt.Errorf("failed to send email to %s: %w", data.To, lastErr)
+}
+
+// encodeMessage serializes msg for the SMTP and file transports, DKIM-signed
+// when keys are configured for the sender's domain
+func encodeMessage(msg Message) (rawMessage, error) {
+	return EncodeSigned(msg, senderKeys(msg.FromAddress))
+}
+
+// toMIME builds the go-mail message behind encodeMessage
+func toMIME(msg Message) *mail.Message {
+	m := mail.NewMessage()
+	m.SetAddressHeader("From", msg.FromAddress, msg.FromName)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetHeader("Message-ID", newMessageID(msg.FromAddress))
+	if msg.TextBody != "" {
+		m.SetBody("text/plain", msg.TextBody)
+		m.AddAlternative("text/html", msg.HTMLBody)
+	} else {
+		m.SetBody("text/html", msg.HTMLBody)
+	}
+	return m
+}
+
+// newMessageID returns a unique Message-ID under the sender's domain
+func newMessageID(fromAddress string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 && at < len(fromAddress)-1 {
+		domain = fromAddress[at+1:]
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
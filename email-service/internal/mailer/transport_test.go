@@ -0,0 +1,245 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger(false)
+	config.AppConfig = &config.Config{
+		SMTPFromName:    "Test Sender",
+		MailFromAddress: "no-reply@example.com",
+	}
+	os.Exit(m.Run())
+}
+
+// failingTransport records that it was tried and fails with err
+type failingTransport struct {
+	name  string
+	err   error
+	tried int
+}
+
+func (t *failingTransport) Name() string { return t.name }
+
+func (t *failingTransport) Send(ctx context.Context, msg Message) (string, error) {
+	t.tried++
+	return "", t.err
+}
+
+// useTransports swaps the configured transports for the duration of a test
+func useTransports(t *testing.T, list ...Transport) {
+	t.Helper()
+	saved := transports
+	transports = list
+	Captured.Reset()
+	t.Cleanup(func() {
+		transports = saved
+		Captured.Reset()
+	})
+}
+
+func testJob(to string) models.EmailJob {
+	return models.EmailJob{
+		To:       to,
+		Subject:  "Your code",
+		HTMLBody: "<p>123456</p>",
+		TextBody: "123456",
+	}
+}
+
+func TestSendEmailFailsOverInOrder(t *testing.T) {
+	first := &failingTransport{name: "first", err: errors.New("connection refused")}
+	second := &failingTransport{name: "second", err: errors.New("timeout")}
+	useTransports(t, first, second, Captured)
+
+	delivery, err := SendEmail(testJob("user@example.net"))
+	if err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if first.tried != 1 || second.tried != 1 {
+		t.Errorf("tried first %d and second %d times, want once each", first.tried, second.tried)
+	}
+	if delivery.Transport != TransportMemory {
+		t.Errorf("delivered via %q, want %q", delivery.Transport, TransportMemory)
+	}
+
+	captured := Captured.Messages("user@example.net")
+	if len(captured) != 1 {
+		t.Fatalf("captured %d messages, want 1", len(captured))
+	}
+	msg := captured[0].Message
+	if msg.FromAddress != "no-reply@example.com" || msg.FromName != "Test Sender" {
+		t.Errorf("sender = %q <%s>, want Test Sender <no-reply@example.com>", msg.FromName, msg.FromAddress)
+	}
+	if msg.Subject != "Your code" || msg.TextBody != "123456" {
+		t.Errorf("captured message = %+v", msg)
+	}
+}
+
+func TestSendEmailStopsAtFirstSuccess(t *testing.T) {
+	after := &failingTransport{name: "after", err: errors.New("must not be called")}
+	useTransports(t, Captured, after)
+
+	if _, err := SendEmail(testJob("user@example.net")); err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if after.tried != 0 {
+		t.Errorf("transport after a successful one was tried %d times", after.tried)
+	}
+}
+
+func TestSendEmailPermanentErrorStopsFailover(t *testing.T) {
+	rejecting := &failingTransport{name: "rejecting", err: &PermanentError{Code: 550, Reason: "no such user"}}
+	useTransports(t, rejecting, Captured)
+
+	delivery, err := SendEmail(testJob("gone@example.net"))
+	if _, ok := IsPermanent(err); !ok {
+		t.Fatalf("err = %v, want a PermanentError", err)
+	}
+	if delivery.Transport != "rejecting" {
+		t.Errorf("delivery transport = %q, want rejecting", delivery.Transport)
+	}
+	if n := len(Captured.Messages("")); n != 0 {
+		t.Errorf("captured %d messages after a permanent rejection, want 0", n)
+	}
+}
+
+func TestSendEmailAllTransportsFail(t *testing.T) {
+	useTransports(t,
+		&failingTransport{name: "first", err: errors.New("first down")},
+		&failingTransport{name: "second", err: errors.New("second down")},
+	)
+
+	_, err := SendEmail(testJob("user@example.net"))
+	if err == nil || !strings.Contains(err.Error(), "second down") {
+		t.Fatalf("err = %v, want the last transport's error", err)
+	}
+}
+
+func TestMemoryTransportFiltersAndResets(t *testing.T) {
+	useTransports(t, Captured)
+
+	for _, to := range []string{"a@example.net", "b@example.net", "a@example.net"} {
+		if _, err := SendEmail(testJob(to)); err != nil {
+			t.Fatalf("SendEmail to %s: %v", to, err)
+		}
+	}
+	if n := len(Captured.Messages("a@example.net")); n != 2 {
+		t.Errorf("captured %d messages to a@, want 2", n)
+	}
+	if n := len(Captured.Messages("")); n != 3 {
+		t.Errorf("captured %d messages in total, want 3", n)
+	}
+
+	Captured.Reset()
+	if n := len(Captured.Messages("")); n != 0 {
+		t.Errorf("captured %d messages after Reset, want 0", n)
+	}
+}
+
+func TestInitTransportsKeepsConfiguredOrder(t *testing.T) {
+	saved := *config.AppConfig
+	t.Cleanup(func() { *config.AppConfig = saved })
+	useTransports(t)
+
+	config.AppConfig.MailTransports = []string{TransportMemory, TransportFile, TransportSMTP}
+	config.AppConfig.MailFileDir = t.TempDir()
+	if err := InitTransports(); err != nil {
+		t.Fatalf("InitTransports: %v", err)
+	}
+
+	var names []string
+	for _, tr := range transports {
+		names = append(names, tr.Name())
+	}
+	if got := strings.Join(names, ","); got != "memory,file,smtp" {
+		t.Errorf("transports = %s, want memory,file,smtp", got)
+	}
+
+	config.AppConfig.MailTransports = []string{"carrier-pigeon"}
+	if err := InitTransports(); err == nil {
+		t.Error("InitTransports accepted an unknown transport")
+	}
+	config.AppConfig.MailTransports = []string{TransportHTTP}
+	config.AppConfig.MailHTTPURL = ""
+	if err := InitTransports(); err == nil {
+		t.Error("InitTransports accepted the http transport without MAIL_HTTP_URL")
+	}
+}
+
+func TestFileTransportWritesMaildir(t *testing.T) {
+	dir := t.TempDir()
+	ft, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatalf("NewFileTransport: %v", err)
+	}
+	useTransports(t, ft)
+
+	delivery, err := SendEmail(testJob("user@example.net"))
+	if err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if delivery.Transport != TransportFile {
+		t.Errorf("delivered via %q, want %q", delivery.Transport, TransportFile)
+	}
+	if filepath.Dir(delivery.Response) != filepath.Join(dir, "new") {
+		t.Errorf("file written to %s, want it under new/", delivery.Response)
+	}
+
+	for _, sub := range []string{"tmp", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			t.Fatalf("read %s: %v", sub, err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("%s/ holds %d files, want none", sub, len(entries))
+		}
+	}
+
+	raw, err := os.ReadFile(delivery.Response)
+	if err != nil {
+		t.Fatalf("read delivered file: %v", err)
+	}
+	for _, want := range []string{
+		"To: user@example.net",
+		"Subject: Your code",
+		"Message-ID: <",
+		"@example.com>",
+		"text/plain",
+		"text/html",
+		"123456",
+	} {
+		if !bytes.Contains(raw, []byte(want)) {
+			t.Errorf("delivered file is missing %q", want)
+		}
+	}
+}
+
+func TestFileTransportUniqueNames(t *testing.T) {
+	ft, err := NewFileTransport(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileTransport: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		path, err := ft.Send(context.Background(), Message{FromAddress: "no-reply@example.com", To: "user@example.net", HTMLBody: "<p>hi</p>"})
+		if err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if seen[path] {
+			t.Fatalf("file %s written twice", path)
+		}
+		seen[path] = true
+	}
+}
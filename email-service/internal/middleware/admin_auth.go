@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"email-service/internal/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware requires the X-Admin-Token header to match ADMIN_TOKEN.
+// When no token is configured the admin endpoints are disabled.
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.AppConfig.AdminToken
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin endpoints are disabled"})
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			return
+		}
+
+		c.Next()
+	}
+}
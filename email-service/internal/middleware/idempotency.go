@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/redis"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader lets callers retry a send without queueing it twice
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyKeyContext holds the scoped key of the current request
+	IdempotencyKeyContext = "idempotencyKey"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyPendingTTL bounds how long a crashed request blocks its key
+	idempotencyPendingTTL = time.Minute
+)
+
+// IdempotencyMiddleware replays the stored response when a request repeats an
+// Idempotency-Key. Keys are scoped to the route and bound to the request body;
+// reusing one with a different body is rejected. Server errors release the key
+// so the request can be retried. Without Redis requests pass through unchecked.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		scoped := c.FullPath() + ":" + key
+		ctx := c.Request.Context()
+		existing, err := redis.ReserveIdempotencyKey(ctx, scoped, fingerprint, idempotencyPendingTTL)
+		if err != nil {
+			logger.Error("Idempotency check failed, continuing without it: %v", err)
+			c.Next()
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case !existing.Completed:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		c.Set(IdempotencyKeyContext, scoped)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := redis.ReleaseIdempotencyKey(ctx, scoped); err != nil {
+				logger.Error("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		record := redis.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := redis.CompleteIdempotencyKey(ctx, scoped, record, config.AppConfig.IdempotencyTTL); err != nil {
+			logger.Error("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// responseRecorder copies the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
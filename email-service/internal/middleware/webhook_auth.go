@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"email-service/internal/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookAuthMiddleware requires BOUNCE_WEBHOOK_TOKEN in the X-Webhook-Token header,
+// or in ?token= for providers that cannot set headers. When no token is configured
+// the webhook is disabled.
+func WebhookAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.AppConfig.BounceWebhookToken
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Bounce webhook is disabled"})
+			return
+		}
+
+		token := c.GetHeader("X-Webhook-Token")
+		if token == "" {
+			token = c.Query("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook token"})
+			return
+		}
+
+		c.Next()
+	}
+}
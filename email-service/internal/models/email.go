@@ -1,17 +1,59 @@
 package models
 
-import "github.com/streadway/amqp"
+import "time"
+
+// Job priorities. High-priority jobs (OTPs, sign-in links, security notices)
+// and bulk jobs travel on separate queues with their own workers.
+const (
+	PriorityHigh = "high"
+	PriorityBulk = "bulk"
+)
 
 type EmailJob struct {
+	// ID identifies the job across redeliveries; the consumer drops copies of a sent ID
+	ID       string `json:"id,omitempty"`
 	To       string `json:"to"`
 	Subject  string `json:"subject"`
 	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body,omitempty"`
+	Locale   string `json:"locale,omitempty"` // locale the email was rendered in
+	// Priority is PriorityHigh or PriorityBulk; empty is high
+	Priority string `json:"priority,omitempty"`
+	// SendAt holds the job back until the given time
+	SendAt *time.Time `json:"send_at,omitempty"`
 }
 
 type OTPRequest struct {
-	Email string `json:"email" binding:"required,email"`
-	OTP   string `json:"otp" binding:"required,len=6"`
+	Email  string `json:"email" binding:"required,email"`
+	OTP    string `json:"otp" binding:"required,len=6"`
+	Locale string `json:"locale" binding:"omitempty,max=35"`
+}
+
+type MagicLinkRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Link   string `json:"link" binding:"required,url"`
+	Locale string `json:"locale" binding:"omitempty,max=35"`
+}
+
+type RecoveryCodesLowRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Remaining int    `json:"remaining" binding:"min=0"`
+	Locale    string `json:"locale" binding:"omitempty,max=35"`
+}
+
+type SendTemplateRequest struct {
+	Email    string                 `json:"email" binding:"required,email"`
+	Template string                 `json:"template" binding:"required"`
+	Data     map[string]interface{} `json:"data"`
+	Locale   string                 `json:"locale" binding:"omitempty,max=35"`
+	// Priority overrides the template's default queue
+	Priority string `json:"priority" binding:"omitempty,oneof=high bulk"`
+	// SendAt schedules the email (RFC 3339); a time in the past sends now
+	SendAt *time.Time `json:"send_at"`
 }
 
-// RabbitMQ channel shared between producer/consumer
-var EmailChannel *amqp.Channel
+type TestSendRequest struct {
+	Email  string                 `json:"email" binding:"required,email"`
+	Locale string                 `json:"locale" binding:"omitempty,max=35"`
+	Data   map[string]interface{} `json:"data"` // overrides the template's sample data
+}
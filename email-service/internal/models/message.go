@@ -0,0 +1,104 @@
+package models
+
+import "time"
+
+// MessageState is where an email is in its delivery lifecycle:
+//
+//	accepted → queued → sending → sent
+//	    ↓                 ↓ ↑      ↓
+//	scheduled ──────→  deferred  bounced
+//
+// A scheduled message moves to sending once its send_at has passed. Any
+// non-final state may become dropped; cancelling a scheduled message drops it. A dropped message returns to queued
+// when its dead letter is replayed, and a sent one may still bounce later.
+type MessageState string
+
+const (
+	MessageAccepted  MessageState = "accepted"  // rendered and stored, not yet on the queue
+	MessageQueued    MessageState = "queued"    // confirmed by the broker
+	MessageScheduled MessageState = "scheduled" // confirmed by the broker, held until send_at
+	MessageSending   MessageState = "sending"   // picked up by a worker
+	MessageSent      MessageState = "sent"      // accepted by a provider
+	MessageDeferred  MessageState = "deferred"  // failed, retry scheduled
+	MessageBounced   MessageState = "bounced"   // recipient rejected permanently
+	MessageDropped   MessageState = "dropped"   // given up: suppressed, unqueueable or out of attempts
+)
+
+// messageTransitions lists the states each state may move to
+var messageTransitions = map[MessageState][]MessageState{
+	// A worker can pick a job up before the broker's confirm is recorded
+	MessageAccepted:  {MessageQueued, MessageScheduled, MessageSending, MessageDropped},
+	MessageQueued:    {MessageSending, MessageDropped},
+	MessageScheduled: {MessageSending, MessageDropped},
+	MessageSending:   {MessageSent, MessageDeferred, MessageBounced, MessageDropped},
+	MessageDeferred:  {MessageSending, MessageDropped},
+	MessageSent:      {MessageBounced},
+	MessageDropped:   {MessageQueued},
+}
+
+// CanTransition reports whether a message may move from one state to another
+func (from MessageState) CanTransition(to MessageState) bool {
+	for _, allowed := range messageTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Final reports whether no more delivery work is expected in this state
+func (s MessageState) Final() bool {
+	return s == MessageSent || s == MessageBounced || s == MessageDropped
+}
+
+// EmailMessage is one email from acceptance to its final outcome. Its ID is
+// the job ID carried on the queue.
+type EmailMessage struct {
+	ID        string       `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Recipient string       `gorm:"index;not null" json:"recipient"`
+	Template  string       `json:"template,omitempty"`
+	Subject   string       `json:"subject"`
+	Locale    string       `json:"locale,omitempty"`
+	Priority  string       `gorm:"type:varchar(8)" json:"priority,omitempty"`
+	State     MessageState `gorm:"type:varchar(16);index;not null" json:"state"`
+	// Reason explains a deferred, bounced or dropped state
+	Reason   string `json:"reason,omitempty"`
+	Attempts int    `gorm:"not null;default:0" json:"attempts"`
+	// Transport and ProviderResponse describe the last send attempt
+	Transport        string `json:"transport,omitempty"`
+	ProviderResponse string `json:"provider_response,omitempty"`
+
+	AcceptedAt     time.Time  `gorm:"not null" json:"accepted_at"`
+	SendAt         *time.Time `json:"send_at,omitempty"`
+	QueuedAt       *time.Time `json:"queued_at,omitempty"`
+	FirstAttemptAt *time.Time `json:"first_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CompletedAt    *time.Time `gorm:"index" json:"completed_at,omitempty"`
+
+	Events    []MessageEvent `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"events,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TableName returns the database table name for the EmailMessage model
+func (EmailMessage) TableName() string {
+	return "email_messages"
+}
+
+// MessageEvent records one state transition of an EmailMessage
+type MessageEvent struct {
+	ID        uint         `gorm:"primarykey" json:"-"`
+	MessageID string       `gorm:"type:varchar(64);index;not null" json:"-"`
+	From      MessageState `gorm:"type:varchar(16)" json:"from,omitempty"`
+	To        MessageState `gorm:"type:varchar(16);not null" json:"to"`
+	Attempt   int          `json:"attempt,omitempty"`
+	Detail    string       `json:"detail,omitempty"`
+	At        time.Time    `gorm:"not null" json:"at"`
+}
+
+// TableName returns the database table name for the MessageEvent model
+func (MessageEvent) TableName() string {
+	return "email_message_events"
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// OutboxEntry is an email job waiting to be published to RabbitMQ. It is written
+// in the same transaction as the job's EmailMessage, so a job is either both
+// recorded and eventually published, or neither.
+type OutboxEntry struct {
+	ID        uint   `gorm:"primarykey"`
+	MessageID string `gorm:"type:varchar(64);index;not null"`
+	// Payload is the EmailJob as JSON, exactly as it will be published
+	Payload       []byte `gorm:"not null"`
+	Attempts      int    `gorm:"not null;default:0"`
+	LastError     string
+	NextAttemptAt time.Time  `gorm:"index;not null"`
+	PublishedAt   *time.Time `gorm:"index"`
+	// LeaseID and LeasedUntil mark an entry claimed by one relay pass. Publishing
+	// happens outside any transaction; an expired lease lets another pass retry it.
+	LeaseID     string `gorm:"type:varchar(32)"`
+	LeasedUntil *time.Time
+	CreatedAt   time.Time
+}
+
+// TableName returns the database table name for the OutboxEntry model
+func (OutboxEntry) TableName() string {
+	return "email_outbox"
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Suppression reasons
+const (
+	SuppressionHardBounce = "hard_bounce"
+	SuppressionComplaint  = "complaint"
+	SuppressionManual     = "manual"
+)
+
+// Suppression sources
+const (
+	SuppressionSourceSMTP    = "smtp"
+	SuppressionSourceWebhook = "webhook"
+	SuppressionSourceAdmin   = "admin"
+)
+
+// Suppression blocks email to an address, permanently or until ExpiresAt
+type Suppression struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	Address   string     `gorm:"uniqueIndex;not null" json:"address"` // lowercased
+	Reason    string     `gorm:"not null" json:"reason"`
+	Source    string     `gorm:"not null" json:"source"`
+	Detail    string     `json:"detail,omitempty"` // provider diagnostic, e.g. the SMTP reply
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the database table name for the Suppression model
+func (Suppression) TableName() string {
+	return "email_suppressions"
+}
+
+type SuppressionRequest struct {
+	Email     string     `json:"email" binding:"required,email"`
+	Reason    string     `json:"reason" binding:"omitempty,oneof=hard_bounce complaint manual"`
+	Detail    string     `json:"detail" binding:"max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// BounceEvent is one provider notification posted to /webhooks/bounces. The
+// generic format uses event "bounce" or "complaint" with type "permanent" or
+// "transient"; SendGrid's event webhook ("bounce", "dropped", "spamreport") also fits.
+type BounceEvent struct {
+	Email  string `json:"email"`
+	Event  string `json:"event"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	// MessageID is the message_id returned when the email was queued, if the
+	// provider echoes it back; the message is then marked bounced
+	MessageID string `json:"message_id"`
+}
@@ -0,0 +1,248 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"email-service/internal/queue"
+	"email-service/internal/tracking"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// relayBatchSize is how many entries one relay pass claims and publishes
+	relayBatchSize = 100
+	// leaseDuration is how long a claimed batch belongs to one relay pass. It is far
+	// longer than a pass takes; if a relay dies mid-pass its entries are picked up
+	// again once the lease runs out, and the consumer drops any duplicate by job ID.
+	leaseDuration = 5 * time.Minute
+	// maxRetryBackoff caps the wait between publish attempts for one entry
+	maxRetryBackoff = time.Minute
+)
+
+var (
+	// wake nudges the relay after a submit so jobs do not wait for the next poll
+	wake        = make(chan struct{}, 1)
+	relayCancel context.CancelFunc
+	relayDone   chan struct{}
+)
+
+// Submit records job as accepted and adds it to the outbox in one transaction.
+// The relay publishes it from there, so a crash cannot lose an accepted job.
+// Resubmitting a job ID that was already accepted adds nothing.
+func Submit(job models.EmailJob, template string) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		created, err := tracking.Accept(tx, job, template)
+		if err != nil || !created {
+			return err
+		}
+		return tx.Create(&models.OutboxEntry{
+			MessageID:     job.ID,
+			Payload:       payload,
+			NextAttemptAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StartRelay starts the goroutine that publishes pending outbox entries. It runs
+// on every OUTBOX_POLL_INTERVAL and after each submit. Entries are marked
+// published only once the broker confirms them, so every entry is published at
+// least once across restarts; the consumer drops the occasional duplicate by job ID.
+func StartRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	relayCancel = cancel
+	relayDone = make(chan struct{})
+
+	go func() {
+		defer close(relayDone)
+		ticker := time.NewTicker(config.AppConfig.OutboxPollInterval)
+		defer ticker.Stop()
+
+		for {
+			// Keep going while whole batches are published
+			for ctx.Err() == nil && relay() == relayBatchSize {
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+	logger.Info("Outbox relay started (poll every %s)", config.AppConfig.OutboxPollInterval)
+}
+
+// StopRelay stops the relay and waits for the pass in progress to finish
+func StopRelay() {
+	if relayCancel == nil {
+		return
+	}
+	relayCancel()
+	<-relayDone
+	logger.Info("Outbox relay stopped")
+}
+
+// relay publishes one batch of due entries and returns how many were published.
+// The batch is claimed with a lease in a short transaction (SKIP LOCKED lets several
+// instances claim side by side without double work), published with no transaction
+// or row lock held, and each entry is then marked sent or rescheduled on its own.
+func relay() int {
+	leaseID, entries, err := claim()
+	if err != nil {
+		logger.Error("Outbox relay failed to claim entries: %v", err)
+		return 0
+	}
+
+	published := 0
+	for i, entry := range entries {
+		var job models.EmailJob
+		if err := json.Unmarshal(entry.Payload, &job); err != nil {
+			// Retrying cannot fix a corrupt payload
+			logger.Error("Discarding outbox entry %d with invalid payload: %v", entry.ID, err)
+			now := time.Now()
+			finish(entry, leaseID, map[string]interface{}{"published_at": &now, "last_error": "invalid payload: " + err.Error()})
+			tracking.Record(entry.MessageID, models.MessageDropped, tracking.Change{Reason: "invalid outbox payload"})
+			continue
+		}
+
+		if pubErr := queue.PublishEmailJob(job); pubErr != nil {
+			attempts := entry.Attempts + 1
+			next := time.Now().Add(retryBackoff(attempts))
+			logger.Error("Failed to publish outbox entry %d (attempt %d), retrying at %s: %v",
+				entry.ID, attempts, next.Format(time.RFC3339), pubErr)
+			finish(entry, leaseID, map[string]interface{}{"attempts": attempts, "last_error": pubErr.Error(), "next_attempt_at": next})
+
+			// The broker is down or not confirming: hand the rest of the batch
+			// back for the next pass instead of waiting out each timeout
+			release(entries[i+1:], leaseID)
+			break
+		}
+
+		now := time.Now()
+		finish(entry, leaseID, map[string]interface{}{"published_at": &now})
+		if job.SendAt != nil {
+			tracking.Record(job.ID, models.MessageScheduled, tracking.Change{NextAttemptAt: job.SendAt})
+		} else {
+			tracking.Record(job.ID, models.MessageQueued, tracking.Change{})
+		}
+		published++
+	}
+	return published
+}
+
+// claim leases up to relayBatchSize due entries to a new lease ID, in id order.
+// The row locks last only as long as this transaction.
+func claim() (string, []models.OutboxEntry, error) {
+	leaseID, err := newLeaseID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var entries []models.OutboxEntry
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Where("leased_until IS NULL OR leased_until <= ?", now).
+			Order("id").
+			Limit(relayBatchSize).
+			Find(&entries).Error
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ID
+		}
+		return tx.Model(&models.OutboxEntry{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"lease_id": leaseID, "leased_until": now.Add(leaseDuration)}).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return leaseID, entries, nil
+}
+
+// finish records the outcome of one entry and ends its lease. An entry whose lease
+// expired and was claimed again is left to the pass that holds it now.
+func finish(entry models.OutboxEntry, leaseID string, updates map[string]interface{}) {
+	updates["lease_id"] = ""
+	updates["leased_until"] = nil
+	res := config.DB.Model(&models.OutboxEntry{}).
+		Where("id = ? AND lease_id = ?", entry.ID, leaseID).
+		Updates(updates)
+	if res.Error != nil {
+		// The entry stays leased and is retried when the lease expires
+		logger.Error("Failed to update outbox entry %d: %v", entry.ID, res.Error)
+	} else if res.RowsAffected == 0 {
+		logger.Error("Outbox entry %d lost its lease before it was updated", entry.ID)
+	}
+}
+
+// release ends the lease of entries that were claimed but not attempted
+func release(entries []models.OutboxEntry, leaseID string) {
+	if len(entries) == 0 {
+		return
+	}
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	err := config.DB.Model(&models.OutboxEntry{}).
+		Where("id IN ? AND lease_id = ?", ids, leaseID).
+		Updates(map[string]interface{}{"lease_id": "", "leased_until": nil}).Error
+	if err != nil {
+		logger.Error("Failed to release outbox entries: %v", err)
+	}
+}
+
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// retryBackoff doubles from one second per failed attempt, up to maxRetryBackoff
+func retryBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// Purge deletes entries published before cutoff
+func Purge(cutoff time.Time) (int64, error) {
+	res := config.DB.Where("published_at < ?", cutoff).Delete(&models.OutboxEntry{})
+	return res.RowsAffected, res.Error
+}
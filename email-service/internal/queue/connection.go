@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"context"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	reconnectInitialBackoff = 1 * time.Second
+	reconnectMaxBackoff     = 30 * time.Second
+)
+
+var (
+	// ErrNotConnected is returned while the broker connection is down
+	ErrNotConnected = errors.New("rabbitmq is not connected")
+	// ErrNotConfirmed is returned when the broker nacks or does not confirm a publish in time
+	ErrNotConfirmed = errors.New("rabbitmq did not confirm the message")
+)
+
+// broker holds the live connection. Everything is replaced together on reconnect.
+var broker struct {
+	mu         sync.RWMutex
+	conn       *amqp.Connection
+	consumeCh  *amqp.Channel
+	publishCh  *amqp.Channel // in confirm mode
+	confirms   chan amqp.Confirmation
+	publishTag uint64 // delivery tag of the last publish on publishCh
+}
+
+// publishMu serializes publishes so each one can wait for its own confirm
+var publishMu sync.Mutex
+
+var supervisorCtx, supervisorCancel = context.WithCancel(context.Background())
+
+// InitRabbitMQ starts the connection supervisor, which dials the broker and redials
+// with exponential backoff whenever the connection drops. It waits briefly for the
+// first connection but does not fail startup if the broker is not up yet.
+func InitRabbitMQ() error {
+	logger.Info("Connecting to RabbitMQ at: %s", config.AppConfig.RabbitMQURL)
+
+	connected := make(chan struct{})
+	go supervise(connected)
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		logger.Error("RabbitMQ not reachable yet, continuing to retry in the background")
+	}
+	return nil
+}
+
+// IsConnected reports whether the broker connection is currently up
+func IsConnected() bool {
+	broker.mu.RLock()
+	defer broker.mu.RUnlock()
+	return broker.conn != nil && !broker.conn.IsClosed()
+}
+
+// CloseRabbitMQ stops reconnecting and closes the connection
+func CloseRabbitMQ() {
+	supervisorCancel()
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.conn != nil {
+		if err := broker.conn.Close(); err != nil {
+			logger.Error("Error closing RabbitMQ connection: %v", err)
+		}
+		broker.conn = nil
+	}
+	logger.Info("RabbitMQ connection closed")
+}
+
+func supervise(connected chan struct{}) {
+	backoff := reconnectInitialBackoff
+	signalled := false
+
+	for {
+		if err := connect(); err != nil {
+			wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			logger.Error("Failed to connect to RabbitMQ, retrying in %s: %v", wait, err)
+
+			select {
+			case <-time.After(wait):
+			case <-supervisorCtx.Done():
+				return
+			}
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			continue
+		}
+		backoff = reconnectInitialBackoff
+
+		if !signalled {
+			close(connected)
+			signalled = true
+		}
+
+		broker.mu.RLock()
+		closed := broker.conn.NotifyClose(make(chan *amqp.Error, 1))
+		consumeCh := broker.consumeCh
+		broker.mu.RUnlock()
+
+		// Re-register the consumer on every new connection
+		if consumerRequested() {
+			if err := startConsuming(consumeCh); err != nil {
+				logger.Error("Failed to register email consumer: %v", err)
+			}
+		}
+
+		select {
+		case amqpErr := <-closed:
+			logger.Error("RabbitMQ connection lost: %v", amqpErr)
+			broker.mu.Lock()
+			broker.conn = nil
+			broker.consumeCh = nil
+			broker.publishCh = nil
+			broker.mu.Unlock()
+		case <-supervisorCtx.Done():
+			return
+		}
+	}
+}
+
+// connect dials the broker, declares the topology and opens the consumer and confirm channels
+func connect() error {
+	conn, err := amqp.Dial(config.AppConfig.RabbitMQURL)
+	if err != nil {
+		return err
+	}
+
+	consumeCh, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open consumer channel: %w", err)
+	}
+	if err := declareTopology(consumeCh); err != nil {
+		conn.Close()
+		return err
+	}
+
+	publishCh, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open publish channel: %w", err)
+	}
+	if err := publishCh.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	confirms := publishCh.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	broker.mu.Lock()
+	broker.conn = conn
+	broker.consumeCh = consumeCh
+	broker.publishCh = publishCh
+	broker.confirms = confirms
+	broker.publishTag = 0
+	broker.mu.Unlock()
+
+	logger.Info("RabbitMQ initialized successfully")
+	return nil
+}
+
+// publish sends msg and waits for the broker to confirm it
+func publish(exchange, key string, msg amqp.Publishing) error {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	broker.mu.Lock()
+	ch, confirms := broker.publishCh, broker.confirms
+	if ch == nil {
+		broker.mu.Unlock()
+		return ErrNotConnected
+	}
+	broker.publishTag++
+	tag := broker.publishTag
+	broker.mu.Unlock()
+
+	if err := ch.Publish(exchange, key, false, false, msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotConnected, err)
+	}
+
+	timeout := time.After(config.AppConfig.RabbitMQConfirmTimeout)
+	for {
+		select {
+		case confirm, ok := <-confirms:
+			if !ok {
+				return fmt.Errorf("%w: channel closed", ErrNotConfirmed)
+			}
+			// Skip confirms for earlier publishes that timed out
+			if confirm.DeliveryTag < tag {
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("%w: nacked by broker", ErrNotConfirmed)
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("%w: timed out after %s", ErrNotConfirmed, config.AppConfig.RabbitMQConfirmTimeout)
+		}
+	}
+}
+
+// adminChannel opens a separate channel so admin reads never interleave with the consumer
+func adminChannel() (*amqp.Channel, error) {
+	broker.mu.RLock()
+	conn := broker.conn
+	broker.mu.RUnlock()
+
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	return ch, nil
+}
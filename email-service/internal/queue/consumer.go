@@ -7,18 +7,80 @@ import (
 	"email-service/internal/logger"
 	"email-service/internal/mailer"
 	"email-service/internal/models"
+	"email-service/internal/suppression"
+	"email-service/internal/tracking"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
 )
 
-var consumerCtx context.Context
-var consumerCancel context.CancelFunc
+var (
+	consumerMu     sync.Mutex
+	consumerCtx    context.Context
+	consumerCancel context.CancelFunc
+	// workers tracks worker goroutines across reconnects so shutdown can wait for them
+	workers sync.WaitGroup
+)
 
+// StartEmailConsumer starts consuming now if the broker is connected; the
+// connection supervisor re-registers the consumer after every reconnect
 func StartEmailConsumer() error {
+	consumerMu.Lock()
 	consumerCtx, consumerCancel = context.WithCancel(context.Background())
+	consumerMu.Unlock()
+
+	broker.mu.RLock()
+	ch := broker.consumeCh
+	broker.mu.RUnlock()
+
+	if ch == nil {
+		logger.Info("Email consumer will start once RabbitMQ is connected")
+		return nil
+	}
+	return startConsuming(ch)
+}
+
+// consumerRequested reports whether StartEmailConsumer was called and not stopped
+func consumerRequested() bool {
+	consumerMu.Lock()
+	defer consumerMu.Unlock()
+	return consumerCtx != nil && consumerCtx.Err() == nil
+}
+
+// startConsuming sets the channel's prefetch and starts EMAIL_WORKERS workers on the
+// high-priority queue and EMAIL_BULK_WORKERS on the bulk queue. Workers exit when the
+// channel closes (the supervisor then registers a new one) or when the consumer is
+// stopped; a worker always finishes the job it is handling first.
+func startConsuming(ch *amqp.Channel) error {
+	consumerMu.Lock()
+	ctx := consumerCtx
+	consumerMu.Unlock()
+
+	// Not global: the prefetch applies to each queue's consumer separately
+	if err := ch.Qos(config.AppConfig.RabbitMQPrefetch, 0, false); err != nil {
+		return err
+	}
+
+	if err := consume(ctx, ch, jobQueue(models.PriorityHigh), config.AppConfig.EmailWorkers); err != nil {
+		return err
+	}
+	if err := consume(ctx, ch, jobQueue(models.PriorityBulk), config.AppConfig.EmailBulkWorkers); err != nil {
+		return err
+	}
+
+	logger.Info("Email consumer started with %d workers and %d bulk workers (prefetch %d)",
+		config.AppConfig.EmailWorkers, config.AppConfig.EmailBulkWorkers, config.AppConfig.RabbitMQPrefetch)
+	return nil
+}
 
-	msgs, err := models.EmailChannel.Consume(
-		config.AppConfig.RabbitMQQueue,
+// consume starts n workers on queue
+func consume(ctx context.Context, ch *amqp.Channel, queue string, n int) error {
+	msgs, err := ch.Consume(
+		queue,
 		"",    // consumer
-		true,  // auto-ack
+		false, // auto-ack: jobs are acked only once sent, retried or dead-lettered
 		false, // exclusive
 		false, // no-local
 		false, // no-wait
@@ -28,45 +90,172 @@ func StartEmailConsumer() error {
 		return err
 	}
 
-	go func() {
-		for {
-			select {
-			case msg, ok := <-msgs:
-				if !ok {
-					logger.Info("RabbitMQ channel closed")
+	for i := 0; i < n; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case msg, ok := <-msgs:
+					if !ok {
+						return
+					}
+					handleDelivery(msg)
+				case <-ctx.Done():
 					return
 				}
+			}
+		}()
+	}
+	return nil
+}
 
-				var job models.EmailJob
-				if err := json.Unmarshal(msg.Body, &job); err != nil {
-					logger.Error("Failed to parse email job: %v", err)
-					continue
-				}
+// handleDelivery sends one job and settles the message: ack on success,
+// otherwise republish to a retry queue or the dead-letter queue before acking.
+// If the republish itself fails the message is requeued so it is never lost.
+func handleDelivery(msg amqp.Delivery) {
+	attempt := attemptOf(msg.Headers)
 
-				err := mailer.SendEmail(models.EmailJob{
-					To:       job.To,
-					Subject:  job.Subject,
-					HTMLBody: job.HTMLBody,
-				})
-				if err != nil {
-					logger.Error("Failed to send email: %v", err)
-				} else {
-					logger.SecureInfo("Email sent to: %s", job.To)
-				}
-			case <-consumerCtx.Done():
-				logger.Info("Email consumer stopped")
-				return
-			}
+	var job models.EmailJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		logger.Error("Failed to parse email job, dead-lettering: %v", err)
+		settle(msg, deadLetter(msg, attempt, err))
+		return
+	}
+	queue := jobQueue(job.Priority)
+
+	if job.SendAt != nil {
+		if state, err := tracking.State(job.ID); err == nil && state == models.MessageDropped {
+			logger.Info("Discarding cancelled email job %s", job.ID)
+			settle(msg, nil)
+			return
+		}
+		// Not due yet: wait in the next delay bucket
+		if bucket := scheduleBucket(time.Until(*job.SendAt)); bucket > 0 {
+			settle(msg, publish(retryExchange(), retryQueue(queue, bucket), republishing(msg, attempt, nil)))
+			return
 		}
-	}()
+	}
 
-	logger.Info("Email consumer started")
-	return nil
+	if !claimJob(job) {
+		// The copy that holds the claim owns the message's state
+		logger.Info("Dropping duplicate delivery of email job %s", job.ID)
+		settle(msg, nil)
+		return
+	}
+
+	if s, err := suppression.Find(job.To); err != nil {
+		logger.Error("Failed to check suppression list, sending anyway: %v", err)
+	} else if s != nil {
+		logger.Info("Dropping email job %s: recipient is suppressed (%s)", job.ID, s.Reason)
+		tracking.Record(job.ID, models.MessageDropped, tracking.Change{Attempt: attempt, Reason: "recipient suppressed: " + s.Reason})
+		markJobSent(job)
+		settle(msg, nil)
+		return
+	}
+
+	tracking.Record(job.ID, models.MessageSending, tracking.Change{Attempt: attempt})
+
+	delivery, sendErr := mailer.SendEmail(job)
+	if sendErr == nil {
+		markJobSent(job)
+		logger.SecureInfo("Email sent to: %s", job.To)
+		tracking.Record(job.ID, models.MessageSent, tracking.Change{Attempt: attempt, Transport: delivery.Transport, Response: delivery.Response})
+		settle(msg, nil)
+		return
+	}
+	releaseJob(job)
+
+	if perm, ok := mailer.IsPermanent(sendErr); ok {
+		// The mailbox does not exist: stop retrying and stop future sends
+		logger.Error("Email job %s bounced: %v", job.ID, sendErr)
+		reply := fmt.Sprintf("%d %s", perm.Code, perm.Reason)
+		tracking.Record(job.ID, models.MessageBounced, tracking.Change{Attempt: attempt, Reason: "recipient rejected", Transport: delivery.Transport, Response: reply})
+		if _, err := suppression.Add(job.To, models.SuppressionHardBounce, models.SuppressionSourceSMTP, reply, nil); err != nil {
+			logger.Error("Failed to suppress bounced address: %v", err)
+		}
+		settle(msg, nil)
+		return
+	}
+
+	logger.Error("Failed to send email (attempt %d/%d): %v", attempt, config.AppConfig.EmailMaxAttempts, sendErr)
+
+	if attempt >= config.AppConfig.EmailMaxAttempts {
+		err := deadLetter(msg, attempt, sendErr)
+		if err == nil {
+			tracking.Record(job.ID, models.MessageDropped, tracking.Change{Attempt: attempt, Reason: "dead-lettered after max attempts", Response: sendErr.Error()})
+		}
+		settle(msg, err)
+		return
+	}
+
+	err := scheduleRetry(msg, queue, attempt, sendErr)
+	if err == nil {
+		next := time.Now().Add(retryDelay(attempt))
+		tracking.Record(job.ID, models.MessageDeferred, tracking.Change{Attempt: attempt, Reason: "retry scheduled", Response: sendErr.Error(), NextAttemptAt: &next})
+	}
+	settle(msg, err)
+}
+
+// settle acks the message, or requeues it when it could not be handed on
+func settle(msg amqp.Delivery, republishErr error) {
+	if republishErr != nil {
+		logger.Error("Failed to republish email job, requeueing: %v", republishErr)
+		if err := msg.Nack(false, true); err != nil {
+			logger.Error("Failed to nack email job: %v", err)
+		}
+		return
+	}
+	if err := msg.Ack(false); err != nil {
+		logger.Error("Failed to ack email job: %v", err)
+	}
+}
+
+// scheduleRetry publishes the job to queue's delay queue for its next attempt
+func scheduleRetry(msg amqp.Delivery, queue string, failedAttempt int, cause error) error {
+	delay := retryDelay(failedAttempt)
+	logger.Info("Retrying email job in %s (next attempt %d)", delay, failedAttempt+1)
+
+	return publish(retryExchange(), retryQueue(queue, delay), republishing(msg, failedAttempt+1, cause))
+}
+
+// deadLetter publishes the job to the dead-letter queue
+func deadLetter(msg amqp.Delivery, attempt int, cause error) error {
+	return publish("", deadLetterQueue(), republishing(msg, attempt, cause))
+}
+
+// republishing copies a delivery with an updated attempt header, and failure
+// headers when cause is set
+func republishing(msg amqp.Delivery, attempt int, cause error) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerAttempt] = int32(attempt)
+	if cause != nil {
+		headers[headerLastError] = cause.Error()
+		headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return amqp.Publishing{
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.MessageId,
+		Headers:      headers,
+		Body:         msg.Body,
+	}
 }
 
+// StopEmailConsumer stops taking new jobs and returns once in-flight sends have finished.
+// Prefetched jobs that were not started stay unacked and are redelivered by the broker.
 func StopEmailConsumer() {
+	consumerMu.Lock()
 	if consumerCancel != nil {
 		consumerCancel()
 		logger.Info("Email consumer shutdown signal sent")
 	}
+	consumerMu.Unlock()
+
+	workers.Wait()
+	logger.Info("Email consumer stopped")
 }
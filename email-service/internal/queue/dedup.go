@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"context"
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"email-service/internal/redis"
+	"time"
+)
+
+const (
+	// jobClaimTTL outlasts a send, including provider rate-limit waits, so a
+	// copy delivered meanwhile is dropped; it expires if a worker dies mid-send
+	jobClaimTTL  = 10 * time.Minute
+	dedupTimeout = 2 * time.Second
+)
+
+// claimJob reports whether this delivery of job should be sent. Copies of a job
+// already sent, or being sent by another worker, are not. Jobs without an ID and
+// Redis errors fail open: a possible duplicate beats a lost email.
+func claimJob(job models.EmailJob) bool {
+	if job.ID == "" {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dedupTimeout)
+	defer cancel()
+
+	claimed, err := redis.ClaimJob(ctx, job.ID, jobClaimTTL)
+	if err != nil {
+		logger.Error("Failed to claim email job %s, sending anyway: %v", job.ID, err)
+		return true
+	}
+	return claimed
+}
+
+// markJobSent remembers that job was delivered for IDEMPOTENCY_TTL
+func markJobSent(job models.EmailJob) {
+	if job.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dedupTimeout)
+	defer cancel()
+
+	if err := redis.MarkJobSent(ctx, job.ID, config.AppConfig.IdempotencyTTL); err != nil {
+		logger.Error("Failed to mark email job %s as sent: %v", job.ID, err)
+	}
+}
+
+// releaseJob drops the claim after a failed send so the retried job is not mistaken for a copy
+func releaseJob(job models.EmailJob) {
+	if job.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dedupTimeout)
+	defer cancel()
+
+	if err := redis.ReleaseJob(ctx, job.ID); err != nil {
+		logger.Error("Failed to release email job %s: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"encoding/json"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"email-service/internal/tracking"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+// DeadLetter describes a dead-lettered job without its rendered body
+type DeadLetter struct {
+	MessageID string `json:"message_id,omitempty"`
+	To        string `json:"to"`
+	Subject   string `json:"subject"`
+	Priority  string `json:"priority,omitempty"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error"`
+	FailedAt  string `json:"failed_at"`
+}
+
+// InspectDeadLetters returns up to limit jobs from the head of the DLQ and
+// leaves them in place, along with the total number of dead-lettered jobs
+func InspectDeadLetters(limit int) ([]DeadLetter, int, error) {
+	ch, err := adminChannel()
+	if err != nil {
+		return nil, 0, err
+	}
+	// Closing the channel returns every unacked message to the queue
+	defer ch.Close()
+
+	q, err := ch.QueueInspect(deadLetterQueue())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to inspect dead-letter queue: %w", err)
+	}
+
+	letters := []DeadLetter{}
+	for len(letters) < limit {
+		msg, ok, err := ch.Get(deadLetterQueue(), false)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read dead-letter queue: %w", err)
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, describe(msg))
+	}
+
+	return letters, q.Messages, nil
+}
+
+// ReplayDeadLetters moves up to limit jobs from the DLQ back onto their priority's queue
+// with a fresh attempt count and returns how many were moved. Each job goes through the
+// confirming publish path and is only acked off the DLQ once the broker has confirmed
+// the copy; otherwise it is requeued, so a job is never lost between the two queues.
+func ReplayDeadLetters(limit int) (int, error) {
+	ch, err := adminChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for replayed < limit {
+		msg, ok, err := ch.Get(deadLetterQueue(), false)
+		if err != nil {
+			return replayed, fmt.Errorf("failed to read dead-letter queue: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		headers := amqp.Table{}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		headers[headerAttempt] = int32(1)
+
+		letter := describe(msg)
+		err = publish("", jobQueue(letter.Priority), amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.MessageId,
+			Headers:      headers,
+			Body:         msg.Body,
+		})
+		if err != nil {
+			if nackErr := msg.Nack(false, true); nackErr != nil {
+				logger.Error("Failed to requeue dead letter %s: %v", msg.MessageId, nackErr)
+			}
+			return replayed, fmt.Errorf("failed to republish dead letter: %w", err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return replayed, fmt.Errorf("failed to ack dead letter: %w", err)
+		}
+
+		if letter.MessageID != "" {
+			tracking.Record(letter.MessageID, models.MessageQueued, tracking.Change{Reason: "replayed from dead-letter queue"})
+		}
+		replayed++
+	}
+
+	logger.Info("Replayed %d dead-lettered email jobs", replayed)
+	return replayed, nil
+}
+
+func describe(msg amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		MessageID: msg.MessageId,
+		Attempts:  attemptOf(msg.Headers),
+	}
+	if v, ok := msg.Headers[headerLastError].(string); ok {
+		letter.LastError = v
+	}
+	if v, ok := msg.Headers[headerFailedAt].(string); ok {
+		letter.FailedAt = v
+	}
+
+	var job models.EmailJob
+	if err := json.Unmarshal(msg.Body, &job); err == nil {
+		letter.To = job.To
+		letter.Subject = job.Subject
+		letter.Priority = job.Priority
+	}
+	return letter
+}
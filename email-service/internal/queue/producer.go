@@ -1,60 +1,48 @@
 package queue
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"email-service/internal/config"
 	"email-service/internal/logger"
 	"email-service/internal/models"
+
 	"github.com/streadway/amqp"
 )
 
-func InitRabbitMQ() error {
-	logger.Info("Connecting to RabbitMQ at: %s", config.AppConfig.RabbitMQURL)
-	
-	conn, err := amqp.Dial(config.AppConfig.RabbitMQURL)
-	if err != nil {
-		logger.Error("Failed to connect to RabbitMQ: %v", err)
-		return err
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		logger.Error("Failed to open RabbitMQ channel: %v", err)
-		return err
-	}
-
-	_, err = ch.QueueDeclare(
-		config.AppConfig.RabbitMQQueue,
-		true,  // durable
-		false, // auto-delete
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		logger.Error("Failed to declare RabbitMQ queue: %v", err)
-		return err
+// NewJobID returns a job ID derived from seed, such as an Idempotency-Key, so
+// repeated submissions share it; an empty seed gives a random ID
+func NewJobID(seed string) string {
+	if seed == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		return hex.EncodeToString(b)
 	}
-
-	models.EmailChannel = ch
-	logger.Info("RabbitMQ initialized successfully")
-	return nil
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:16])
 }
 
+// PublishEmailJob queues job on the queue for its priority and returns nil only
+// once the broker has confirmed it. Jobs without an ID get a random one.
 func PublishEmailJob(job models.EmailJob) error {
+	if job.ID == "" {
+		job.ID = NewJobID("")
+	}
 	body, err := json.Marshal(job)
 	if err != nil {
 		return err
 	}
 
-	err = models.EmailChannel.Publish(
+	err = publish(
 		"", // exchange
-		config.AppConfig.RabbitMQQueue,
-		false, // mandatory
-		false, // immediate
+		jobQueue(job.Priority),
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    job.ID,
+			Headers:      amqp.Table{headerAttempt: int32(1)},
+			Body:         body,
 		})
 	if err != nil {
 		return err
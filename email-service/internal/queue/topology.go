@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"email-service/internal/config"
+	"email-service/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// AMQP headers carried by email jobs between attempts
+const (
+	headerAttempt   = "x-attempt"
+	headerLastError = "x-last-error"
+	headerFailedAt  = "x-failed-at"
+)
+
+// scheduleBuckets are the delay queues a scheduled job hops through: each hop
+// waits in the longest bucket that does not overshoot its send_at
+var scheduleBuckets = []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour}
+
+// jobQueue returns the queue for a job priority. Priorities use separate queues
+// rather than x-max-priority, which cannot be added to an existing queue.
+func jobQueue(priority string) string {
+	if priority == models.PriorityBulk {
+		return config.AppConfig.RabbitMQQueue + ".bulk"
+	}
+	return config.AppConfig.RabbitMQQueue
+}
+
+// retryExchange routes failed and scheduled jobs to their delay queue
+func retryExchange() string {
+	return config.AppConfig.RabbitMQQueue + ".retry"
+}
+
+// retryQueue holds jobs for delay, then dead-letters them back onto queue
+func retryQueue(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queue, delay)
+}
+
+// delays returns every delay queue length: the retry delays and the schedule buckets
+func delays() []time.Duration {
+	all := append([]time.Duration{}, config.AppConfig.EmailRetryDelays...)
+	for _, bucket := range scheduleBuckets {
+		found := false
+		for _, d := range all {
+			found = found || d == bucket
+		}
+		if !found {
+			all = append(all, bucket)
+		}
+	}
+	return all
+}
+
+// scheduleBucket returns the delay queue length for a job due in wait, or 0
+// when it is due within the shortest bucket and should be sent now
+func scheduleBucket(wait time.Duration) time.Duration {
+	var bucket time.Duration
+	for _, b := range scheduleBuckets {
+		if b <= wait {
+			bucket = b
+		}
+	}
+	return bucket
+}
+
+// deadLetterQueue holds jobs that failed every attempt or could not be parsed
+func deadLetterQueue() string {
+	return config.AppConfig.RabbitMQQueue + ".dlq"
+}
+
+// retryDelay returns the wait before the attempt after the given failed one
+func retryDelay(failedAttempt int) time.Duration {
+	delays := config.AppConfig.EmailRetryDelays
+	if failedAttempt > len(delays) {
+		return delays[len(delays)-1]
+	}
+	return delays[failedAttempt-1]
+}
+
+// declareTopology declares the high-priority and bulk queues, the retry exchange
+// with one TTL queue per delay for each of them, and the dead-letter queue
+func declareTopology(ch *amqp.Channel) error {
+	queues := []string{jobQueue(models.PriorityHigh), jobQueue(models.PriorityBulk)}
+	for _, queue := range queues {
+		if _, err := ch.QueueDeclare(
+			queue,
+			true,  // durable
+			false, // auto-delete
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+		}
+	}
+
+	if err := ch.ExchangeDeclare(
+		retryExchange(),
+		"direct",
+		true,  // durable
+		false, // auto-delete
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	); err != nil {
+		return fmt.Errorf("failed to declare retry exchange: %w", err)
+	}
+
+	for _, queue := range queues {
+		for _, delay := range delays() {
+			name := retryQueue(queue, delay)
+			if _, err := ch.QueueDeclare(name, true, false, false, false, amqp.Table{
+				"x-message-ttl":             int64(delay / time.Millisecond),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+			}); err != nil {
+				return fmt.Errorf("failed to declare retry queue %s: %w", name, err)
+			}
+			if err := ch.QueueBind(name, name, retryExchange(), false, nil); err != nil {
+				return fmt.Errorf("failed to bind retry queue %s: %w", name, err)
+			}
+		}
+	}
+
+	if _, err := ch.QueueDeclare(deadLetterQueue(), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	return nil
+}
+
+// attemptOf reads the attempt number from a delivery, defaulting to the first attempt
+func attemptOf(headers amqp.Table) int {
+	switch v := headers[headerAttempt].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 1
+}
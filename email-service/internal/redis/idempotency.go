@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyPrefix = "email:idempotency:"
+	jobPrefix         = "email:job:"
+
+	// reserveAttempts bounds how often a key that keeps expiring between
+	// SETNX and GET is claimed again
+	reserveAttempts = 3
+
+	jobStateSending = "sending"
+	jobStateSent    = "sent"
+)
+
+// IdempotencyRecord is what is stored under an Idempotency-Key: the request's
+// fingerprint and, once the request has finished, its response
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// ReserveIdempotencyKey claims key for a new request for ttl. When the key is
+// already taken it returns the existing record instead, and nil otherwise.
+// A key that expires between the claim and the read is claimed again, up to
+// reserveAttempts times.
+func ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	pending, err := json.Marshal(IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < reserveAttempts; attempt++ {
+		ok, err := GetClient().SetNX(ctx, idempotencyPrefix+key, pending, ttl).Result()
+		if err != nil || ok {
+			return nil, err
+		}
+
+		raw, err := GetClient().Get(ctx, idempotencyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existing IdempotencyRecord
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("idempotency key %s expired during %d reservation attempts", key, reserveAttempts)
+}
+
+// CompleteIdempotencyKey stores the final response of the request holding key
+func CompleteIdempotencyKey(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	record.Completed = true
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return GetClient().Set(ctx, idempotencyPrefix+key, raw, ttl).Err()
+}
+
+// ReleaseIdempotencyKey forgets key so the request can be retried
+func ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return GetClient().Del(ctx, idempotencyPrefix+key).Err()
+}
+
+// ClaimJob marks job id as being sent for ttl. It returns false when another
+// delivery of the same job is being sent or has already been sent.
+func ClaimJob(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return GetClient().SetNX(ctx, jobPrefix+id, jobStateSending, ttl).Result()
+}
+
+// MarkJobSent records that job id was delivered, so later copies are dropped for ttl
+func MarkJobSent(ctx context.Context, id string, ttl time.Duration) error {
+	return GetClient().Set(ctx, jobPrefix+id, jobStateSent, ttl).Err()
+}
+
+// ReleaseJob drops the claim on job id after a failed send so its retry can proceed
+func ReleaseJob(ctx context.Context, id string) error {
+	return GetClient().Del(ctx, jobPrefix+id).Err()
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scriptedRedis answers SET NX and GET from a script instead of a server, so
+// the race between them can be replayed exactly
+type scriptedRedis struct {
+	setNX []bool   // successive SET NX results
+	get   [][]byte // successive GET results; nil means the key is gone
+	sets  int
+	gets  int
+}
+
+func (s *scriptedRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		panic("scriptedRedis never dials")
+	}
+}
+
+func (s *scriptedRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		switch cmd := cmd.(type) {
+		case *redis.BoolCmd:
+			cmd.SetVal(s.setNX[s.sets])
+			s.sets++
+		case *redis.StringCmd:
+			if val := s.get[s.gets]; val != nil {
+				cmd.SetVal(string(val))
+			} else {
+				cmd.SetErr(redis.Nil)
+			}
+			s.gets++
+		}
+		return cmd.Err()
+	}
+}
+
+func (s *scriptedRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// useScript points GetClient at script for the duration of a test
+func useScript(t *testing.T, script *scriptedRedis) {
+	t.Helper()
+	saved := redisClient
+	redisClient = redis.NewClient(&redis.Options{Addr: "scripted:0"})
+	redisClient.AddHook(script)
+	t.Cleanup(func() {
+		redisClient.Close()
+		redisClient = saved
+	})
+}
+
+func TestReserveIdempotencyKeyRetriesWhenKeyExpires(t *testing.T) {
+	script := &scriptedRedis{setNX: []bool{false, true}, get: [][]byte{nil}}
+	useScript(t, script)
+
+	existing, err := ReserveIdempotencyKey(context.Background(), "k", "fp", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if existing != nil {
+		t.Fatalf("got existing record %+v, want the key reserved", existing)
+	}
+	if script.sets != 2 {
+		t.Errorf("SET NX ran %d times, want 2", script.sets)
+	}
+}
+
+func TestReserveIdempotencyKeyReturnsExisting(t *testing.T) {
+	done, _ := json.Marshal(IdempotencyRecord{Fingerprint: "fp", Completed: true, Status: 202})
+	script := &scriptedRedis{setNX: []bool{false, false}, get: [][]byte{nil, done}}
+	useScript(t, script)
+
+	existing, err := ReserveIdempotencyKey(context.Background(), "k", "fp", time.Minute)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if existing == nil || !existing.Completed || existing.Status != 202 {
+		t.Fatalf("existing = %+v, want the completed record", existing)
+	}
+}
+
+func TestReserveIdempotencyKeyGivesUp(t *testing.T) {
+	script := &scriptedRedis{
+		setNX: make([]bool, reserveAttempts),
+		get:   make([][]byte, reserveAttempts),
+	}
+	useScript(t, script)
+
+	existing, err := ReserveIdempotencyKey(context.Background(), "k", "fp", time.Minute)
+	if err == nil {
+		t.Fatalf("existing = %+v, want an error after %d attempts", existing, reserveAttempts)
+	}
+	if script.sets != reserveAttempts {
+		t.Errorf("SET NX ran %d times, want %d", script.sets, reserveAttempts)
+	}
+}
@@ -0,0 +1,88 @@
+package suppression
+
+import (
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Find returns the active suppression of addr, or nil when mail may be sent to it
+func Find(addr string) (*models.Suppression, error) {
+	var s models.Suppression
+	err := config.DB.
+		Where("address = ? AND (expires_at IS NULL OR expires_at > ?)", normalize(addr), time.Now()).
+		First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Add suppresses addr, replacing any earlier suppression of it. Hard bounces
+// expire after SUPPRESSION_BOUNCE_TTL unless expiresAt is given.
+func Add(addr, reason, source, detail string, expiresAt *time.Time) (*models.Suppression, error) {
+	if expiresAt == nil && reason == models.SuppressionHardBounce && config.AppConfig.SuppressionBounceTTL > 0 {
+		until := time.Now().Add(config.AppConfig.SuppressionBounceTTL)
+		expiresAt = &until
+	}
+
+	s := models.Suppression{
+		Address:   normalize(addr),
+		Reason:    reason,
+		Source:    source,
+		Detail:    truncate(detail, 500),
+		ExpiresAt: expiresAt,
+	}
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "source", "detail", "expires_at", "updated_at"}),
+	}).Create(&s).Error
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Suppressed address (%s via %s)", reason, source)
+	return &s, nil
+}
+
+// Remove lifts the suppression of addr; it reports false when there was none
+func Remove(addr string) (bool, error) {
+	res := config.DB.Where("address = ?", normalize(addr)).Delete(&models.Suppression{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// List returns suppressions newest first, optionally only those of addr, and the total count
+func List(addr string, limit, offset int) ([]models.Suppression, int64, error) {
+	query := config.DB.Model(&models.Suppression{})
+	if addr != "" {
+		query = query.Where("address = ?", normalize(addr))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := make([]models.Suppression, 0)
+	err := query.Order("updated_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func normalize(addr string) string {
+	return strings.ToLower(strings.TrimSpace(addr))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,189 @@
+package tracking
+
+import (
+	"email-service/internal/config"
+	"email-service/internal/logger"
+	"email-service/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotFound is returned for message IDs that were never accepted
+	ErrNotFound = errors.New("message not found")
+	// ErrInvalidTransition is returned when a message cannot move to the requested state
+	ErrInvalidTransition = errors.New("invalid message state transition")
+	// ErrNotScheduled is returned when cancelling a message that is not waiting for its send_at
+	ErrNotScheduled = errors.New("message is not scheduled")
+)
+
+// Change carries the details recorded with a transition
+type Change struct {
+	Attempt       int
+	Reason        string
+	Transport     string
+	Response      string
+	NextAttemptAt *time.Time
+}
+
+// Accept records a newly rendered job in the accepted state within tx and
+// reports whether it was new. Accepting a job ID twice (a resubmitted
+// Idempotency-Key) keeps the original message.
+func Accept(tx *gorm.DB, job models.EmailJob, template string) (bool, error) {
+	now := time.Now()
+	msg := models.EmailMessage{
+		ID:         job.ID,
+		Recipient:  job.To,
+		Template:   template,
+		Subject:    job.Subject,
+		Locale:     job.Locale,
+		Priority:   job.Priority,
+		SendAt:     job.SendAt,
+		State:      models.MessageAccepted,
+		AcceptedAt: now,
+	}
+
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&msg)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	err := tx.Create(&models.MessageEvent{MessageID: msg.ID, To: models.MessageAccepted, At: now}).Error
+	return err == nil, err
+}
+
+// Transition moves message id to state to and records the change as an event
+func Transition(id string, to models.MessageState, change Change) error {
+	return transition(id, to, change, "")
+}
+
+// Cancel drops a scheduled message before its send_at. Workers holding the job
+// check for this and discard it.
+func Cancel(id string) error {
+	err := transition(id, models.MessageDropped, Change{Reason: "cancelled"}, models.MessageScheduled)
+	if errors.Is(err, ErrInvalidTransition) {
+		return ErrNotScheduled
+	}
+	return err
+}
+
+// State returns message id's current state
+func State(id string) (models.MessageState, error) {
+	var msg models.EmailMessage
+	err := config.DB.Select("state").First(&msg, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNotFound
+	}
+	return msg.State, err
+}
+
+// transition implements Transition; a non-empty require also requires the
+// message to be in that state
+func transition(id string, to models.MessageState, change Change, require models.MessageState) error {
+	now := time.Now()
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var msg models.EmailMessage
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&msg, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if require != "" && msg.State != require {
+			return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, msg.State, to)
+		}
+		if (to == models.MessageQueued || to == models.MessageScheduled) && msg.State != models.MessageAccepted && msg.State != models.MessageDropped {
+			// The confirm arrived after a worker started on the job, or a duplicate
+			// submission was queued again: keep the message's progress
+			if msg.QueuedAt != nil {
+				return nil
+			}
+			msg.QueuedAt = &now
+			return tx.Save(&msg).Error
+		}
+		if !msg.State.CanTransition(to) {
+			return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, msg.State, to)
+		}
+
+		from := msg.State
+		msg.State = to
+		msg.Reason = change.Reason
+		msg.NextAttemptAt = nil
+		switch to {
+		case models.MessageQueued:
+			msg.QueuedAt = &now
+			msg.CompletedAt = nil
+		case models.MessageSending:
+			msg.Attempts++
+			if msg.FirstAttemptAt == nil {
+				msg.FirstAttemptAt = &now
+			}
+			msg.LastAttemptAt = &now
+		case models.MessageSent:
+			msg.SentAt = &now
+		case models.MessageDeferred:
+			msg.NextAttemptAt = change.NextAttemptAt
+		}
+		if to.Final() {
+			msg.CompletedAt = &now
+		}
+		if change.Transport != "" {
+			msg.Transport = change.Transport
+		}
+		if change.Response != "" {
+			msg.ProviderResponse = change.Response
+		}
+
+		if err := tx.Save(&msg).Error; err != nil {
+			return err
+		}
+		detail := change.Reason
+		if detail == "" {
+			detail = change.Response
+		}
+		return tx.Create(&models.MessageEvent{
+			MessageID: id,
+			From:      from,
+			To:        to,
+			Attempt:   change.Attempt,
+			Detail:    detail,
+			At:        now,
+		}).Error
+	})
+}
+
+// Record is Transition for callers that carry on regardless: failures are only
+// logged, and messages accepted before tracking existed are skipped silently
+func Record(id string, to models.MessageState, change Change) {
+	if id == "" {
+		return
+	}
+	if err := Transition(id, to, change); err != nil && !errors.Is(err, ErrNotFound) {
+		logger.Error("Failed to record message %s as %s: %v", id, to, err)
+	}
+}
+
+// Get returns message id with its events in order
+func Get(id string) (*models.EmailMessage, error) {
+	var msg models.EmailMessage
+	err := config.DB.
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("at, id") }).
+		First(&msg, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Purge deletes finished messages completed before cutoff; their events go with them
+func Purge(cutoff time.Time) (int64, error) {
+	res := config.DB.Where("completed_at < ?", cutoff).Delete(&models.EmailMessage{})
+	return res.RowsAffected, res.Error
+}
@@ -12,8 +12,9 @@ import (
 	"email-service/internal/cleanup"
 	"email-service/internal/config"
 	"email-service/internal/logger"
+	"email-service/internal/mailer"
 	"email-service/internal/middleware"
-	"email-service/internal/models"
+	"email-service/internal/outbox"
 	"email-service/internal/queue"
 	"email-service/internal/redis"
 
@@ -24,7 +25,12 @@ func main() {
 	// Load configuration and dependencies
 	config.LoadConfig()
 	logger.InitLogger(config.AppConfig.APP_MODE == "development")
-	
+
+	// "email-service render ..." renders templates offline and exits
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(runRender(os.Args[2:]))
+	}
+
 	// Initialize database
 	if err := config.InitDatabase(); err != nil {
 		logger.Error("Failed to initialize database: %v", err)
@@ -43,6 +49,24 @@ func main() {
 		os.Exit(1)
 	}
 	
+	// Parse email templates once
+	if err := mailer.InitTemplates("templates"); err != nil {
+		logger.Error("Failed to load email templates: %v", err)
+		os.Exit(1)
+	}
+
+	// Load DKIM signing keys
+	if err := mailer.InitDKIM(); err != nil {
+		logger.Error("Failed to initialize DKIM: %v", err)
+		os.Exit(1)
+	}
+
+	// Initialize mail transports
+	if err := mailer.InitTransports(); err != nil {
+		logger.Error("Failed to initialize mail transports: %v", err)
+		os.Exit(1)
+	}
+
 	// Initialize RabbitMQ
 	if err := queue.InitRabbitMQ(); err != nil {
 		logger.Error("Failed to initialize RabbitMQ: %v", err)
@@ -55,7 +79,10 @@ func main() {
 		os.Exit(1)
 	}
 	
-	cleanup.StartAuditPurger()
+	// Publish queued jobs from the outbox
+	outbox.StartRelay()
+
+	cleanup.StartMessagePurger()
 
 	// Start HTTP server
 	srv := startServer()
@@ -71,7 +98,29 @@ func startServer() *http.Server {
 	// Add rate limiting middleware
 	router.Use(middleware.RateLimitMiddleware())
 	
-	router.POST("/send-otp", api.SendOTPHandler)
+	router.GET("/readyz", api.ReadyzHandler)
+
+	router.POST("/send", middleware.IdempotencyMiddleware(), api.SendTemplateHandler)
+	router.POST("/send-otp", middleware.IdempotencyMiddleware(), api.SendOTPHandler)
+	router.POST("/send-magic-link", middleware.IdempotencyMiddleware(), api.SendMagicLinkHandler)
+	router.POST("/send-recovery-codes-low", middleware.IdempotencyMiddleware(), api.SendRecoveryCodesLowHandler)
+	router.GET("/messages/:id", api.GetMessageHandler)
+
+	router.POST("/webhooks/bounces", middleware.WebhookAuthMiddleware(), api.BounceWebhookHandler)
+
+	// Admin endpoints, guarded by X-Admin-Token
+	admin := router.Group("/admin", middleware.AdminAuthMiddleware())
+	admin.GET("/dlq", api.InspectDLQHandler)
+	admin.POST("/dlq/replay", api.ReplayDLQHandler)
+	admin.GET("/captured-emails", api.CapturedEmailsHandler)
+	admin.DELETE("/captured-emails", api.ResetCapturedEmailsHandler)
+	admin.GET("/templates", api.ListTemplatesHandler)
+	admin.GET("/templates/:id/preview", api.PreviewTemplateHandler)
+	admin.POST("/templates/:id/test-send", api.TestSendTemplateHandler)
+	admin.GET("/suppressions", api.ListSuppressionsHandler)
+	admin.POST("/suppressions", api.AddSuppressionHandler)
+	admin.DELETE("/suppressions/:email", api.RemoveSuppressionHandler)
+	admin.POST("/messages/:id/cancel", api.CancelMessageHandler)
 
 	srv := &http.Server{
 		Addr:    ":" + config.AppConfig.Port,
@@ -104,14 +153,11 @@ func gracefulShutdown(srv *http.Server) {
 		os.Exit(1)
 	}
 
+	outbox.StopRelay()
 	queue.StopEmailConsumer()
+	mailer.ClosePool()
 
-	if models.EmailChannel != nil {
-		if err := models.EmailChannel.Close(); err != nil {
-			logger.Error("Error closing RabbitMQ channel: %v", err)
-		}
-		logger.Info("RabbitMQ channel closed")
-	}
+	queue.CloseRabbitMQ()
 
 	// Close Redis connection
 	redis.CloseRedisClient()
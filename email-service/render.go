@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"email-service/internal/mailer"
+)
+
+// runRender implements the render subcommand, used for CI snapshot tests:
+//
+//	email-service render [-locale fr] [-format html|text|subject] [-data '{"OTP":"123456"}'] <template>
+//	email-service render -out snapshots/
+//
+// With a template ID it prints one render to stdout. With -out it renders every
+// template in the default locale and each translation, using sample data, to
+// <out>/<id>.<locale>.html, .txt and .subject.
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	dir := fs.String("templates", "templates", "template directory")
+	locale := fs.String("locale", "", "locale to render, e.g. fr-CA")
+	format := fs.String("format", "html", "output: html, text or subject")
+	data := fs.String("data", "", "JSON object overriding the sample data")
+	out := fs.String("out", "", "render every template and locale into this directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := mailer.InitTemplates(*dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load templates: %v\n", err)
+		return 1
+	}
+
+	if *out != "" {
+		if err := renderAll(*out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: email-service render [flags] <template>")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	var overrides map[string]interface{}
+	if *data != "" {
+		if err := json.Unmarshal([]byte(*data), &overrides); err != nil {
+			fmt.Fprintf(os.Stderr, "-data must be a JSON object: %v\n", err)
+			return 2
+		}
+	}
+
+	rendered, err := renderSample(fs.Arg(0), *locale, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch *format {
+	case "html":
+		fmt.Print(rendered.HTMLBody)
+	case "text":
+		fmt.Print(rendered.TextBody)
+	case "subject":
+		fmt.Println(rendered.Subject)
+	default:
+		fmt.Fprintln(os.Stderr, "-format must be html, text or subject")
+		return 2
+	}
+	return 0
+}
+
+// renderAll writes every template in every locale to dir
+func renderAll(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, id := range mailer.TemplateIDs() {
+		locales := append([]string{""}, mailer.Locales(id)...)
+		for _, locale := range locales {
+			rendered, err := renderSample(id, locale, nil)
+			if err != nil {
+				return err
+			}
+			base := filepath.Join(dir, id+"."+rendered.Locale)
+			files := map[string]string{
+				base + ".html":    rendered.HTMLBody,
+				base + ".txt":     rendered.TextBody,
+				base + ".subject": rendered.Subject + "\n",
+			}
+			for path, content := range files {
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// renderSample renders template id with its sample data and overrides
+func renderSample(id, locale string, overrides map[string]interface{}) (*mailer.Rendered, error) {
+	data, err := mailer.SampleData(id, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	if missing, _ := mailer.MissingFields(id, data); len(missing) > 0 {
+		return nil, fmt.Errorf("%s: missing template data %v", id, missing)
+	}
+	rendered, err := mailer.Render(id, locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	return rendered, nil
+}
+
@@ -8,4 +8,27 @@ const (
 	MaxAttempts = 3
 	MaxResends = 3
 	RateLimitPerMinute = "10000-M"
+	MagicLinkTokenBytes = 32
+
+	// TOTP (RFC 6238)
+	TOTPDigits      = 6
+	TOTPPeriod      = 30 * time.Second
+	TOTPSkewSteps   = 1 // accept codes from one step before/after the current one
+	TOTPSecretBytes = 20
+	TOTPQRCodeSize  = 256
+	// Wrong codes allowed per user across enroll-confirm, login and step-up before
+	// a lockout; three codes are valid at once, so this caps brute-force guessing
+	TOTPMaxFailures   = 5
+	TOTPFailureWindow = 15 * time.Minute
+	TOTPLockout       = 15 * time.Minute
+
+	// Recovery codes
+	RecoveryCodeCount         = 10
+	RecoveryCodeLength        = 10 // characters, shown as two dash-separated groups
+	RecoveryCodesLowThreshold = 3  // email the user once this many or fewer remain
+
+	// OTP delivery
+	DeliveryModeLive = "live"
+	DeliveryModeStub = "stub" // print/write messages locally instead of calling providers
+	DeliveryTimeout  = 5 * time.Second
 )
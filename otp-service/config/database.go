@@ -12,6 +12,7 @@ import (
 
 var DB *gorm.DB
 var sqlDBInstance *gorm.DB // for tracking underlying *sql.DB
+var UserDB *gorm.DB        // user database shared with auth-service (TOTP enrollments)
 
 type DatabaseConfig struct {
 	Host     string
@@ -33,10 +34,33 @@ func GetDatabaseConfig() DatabaseConfig {
 	}
 }
 
-// InitDatabase connects to the existing database (does not create it)
+// InitDatabase connects to the existing databases (does not create them)
 func InitDatabase() error {
 	config := GetDatabaseConfig()
 
+	db, err := openDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	DB = db
+
+	userConfig := config
+	userConfig.DBName = AppConfig.UserDBName
+	udb, err := openDatabase(userConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to user database: %w", err)
+	}
+	UserDB = udb
+
+	log.Println("Connected to PostgreSQL successfully")
+	if err := CreateTable(); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	return nil
+}
+
+// openDatabase opens a GORM connection with the service's pool settings
+func openDatabase(config DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
@@ -46,13 +70,12 @@ func InitDatabase() error {
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
-	DB = db
 	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get sql.DB: %w", err)
+		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
 
 	// Connection pool settings
@@ -60,27 +83,25 @@ func InitDatabase() error {
 	sqlDB.SetMaxIdleConns(5)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
-	log.Println("Connected to PostgreSQL successfully")
-	if err := CreateTable(); err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
-	}
-	return nil
+	return db, nil
 }
 
-// CloseDatabaseConnection safely closes the database connection
+// CloseDatabaseConnection safely closes the database connections
 func CloseDatabaseConnection() {
-	if DB == nil {
-		return
-	}
-	sqlDB, err := DB.DB()
-	if err != nil {
-		log.Printf("Failed to retrieve sql.DB for closing: %v", err)
-		return
-	}
-	if err := sqlDB.Close(); err != nil {
-		log.Printf("Error while closing DB connection: %v", err)
-	} else {
-		log.Println("Database connection closed successfully")
+	for _, db := range []*gorm.DB{DB, UserDB} {
+		if db == nil {
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Printf("Failed to retrieve sql.DB for closing: %v", err)
+			continue
+		}
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Error while closing DB connection: %v", err)
+		} else {
+			log.Println("Database connection closed successfully")
+		}
 	}
 }
 
@@ -89,6 +110,15 @@ func CreateTable() error {
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate tables: %w", err)
 	}
+	// OTP hashes are no longer audited; drop the column left by older versions
+	if DB.Migrator().HasColumn(&models.OTPEvent{}, "otp_hash") {
+		if err := DB.Migrator().DropColumn(&models.OTPEvent{}, "otp_hash"); err != nil {
+			return fmt.Errorf("failed to drop otp_events.otp_hash: %w", err)
+		}
+	}
+	if err := UserDB.AutoMigrate(&models.TOTPEnrollment{}, &models.RecoveryCode{}, &models.DeliveryPreference{}); err != nil {
+		return fmt.Errorf("failed to auto-migrate user tables: %w", err)
+	}
 	log.Println("Database tables created/verified successfully")
 	return nil
 }
\ No newline at end of file
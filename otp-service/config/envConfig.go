@@ -1,9 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -14,6 +16,7 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+	UserDBName string
 
 	// Redis
 	RedisHost     string
@@ -26,6 +29,26 @@ type Config struct {
 	OTPEventTTLDays int
 	OtpServicePort int
 	EmailServiceUrl string
+	MagicLinkBaseURL string
+
+	// OTP hashing
+	OTPPeppers     map[string][]byte // HMAC keys by key ID
+	OTPPepperKeyID string            // key used for new OTPs
+
+	// OTP delivery
+	DeliveryMode          string
+	DeliveryStubFile      string   // stub output file; console when empty
+	DeliveryFallbackOrder []string // channels tried, in order, after the requested one
+	SMSProviderURL        string
+	SMSProviderToken      string
+	VoiceProviderURL      string
+	VoiceProviderToken    string
+	WebhookURL            string
+	WebhookSecret         string
+
+	// TOTP
+	TOTPIssuer        string
+	TOTPEncryptionKey []byte // AES-256 key for TOTP secrets at rest; TOTP is disabled when empty
 }
 
 var AppConfig Config
@@ -39,11 +62,38 @@ func InitConfig() {
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "otp_audit"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+		UserDBName: getEnv("USER_DB_NAME", "users"),
 
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		AppEnv:        getEnv("APP_ENV", "development"),
 		EmailServiceUrl: getEnv("Email_Service_URL","http://localhost:8082"),
+		MagicLinkBaseURL: getEnv("MAGIC_LINK_BASE_URL", "http://localhost:8080/verify-link"),
+		TOTPIssuer:       getEnv("TOTP_ISSUER", "Auth Micro App"),
+
+		DeliveryMode:       getEnv("DELIVERY_MODE", DeliveryModeLive),
+		DeliveryStubFile:   getEnv("DELIVERY_STUB_FILE", ""),
+		SMSProviderURL:     getEnv("SMS_PROVIDER_URL", ""),
+		SMSProviderToken:   getEnv("SMS_PROVIDER_TOKEN", ""),
+		VoiceProviderURL:   getEnv("VOICE_PROVIDER_URL", ""),
+		VoiceProviderToken: getEnv("VOICE_PROVIDER_TOKEN", ""),
+		WebhookURL:         getEnv("DELIVERY_WEBHOOK_URL", ""),
+		WebhookSecret:      getEnv("DELIVERY_WEBHOOK_SECRET", ""),
+	}
+
+	// Validate DELIVERY_MODE
+	if AppConfig.DeliveryMode != DeliveryModeLive && AppConfig.DeliveryMode != DeliveryModeStub {
+		log.Fatalf("Invalid DELIVERY_MODE: expected %q or %q", DeliveryModeLive, DeliveryModeStub)
+	}
+	if AppConfig.DeliveryMode == DeliveryModeStub && AppConfig.AppEnv == "production" {
+		log.Fatalf("DELIVERY_MODE=stub is not allowed in production")
+	}
+
+	// Parse DELIVERY_FALLBACK_ORDER (comma-separated channel names)
+	for _, name := range strings.Split(getEnv("DELIVERY_FALLBACK_ORDER", "email,sms,voice,webhook"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			AppConfig.DeliveryFallbackOrder = append(AppConfig.DeliveryFallbackOrder, name)
+		}
 	}
 
 	// Parse DB_PORT
@@ -75,6 +125,17 @@ func InitConfig() {
 	if err != nil {
 		log.Fatalf("Invalid OTP_SERVICE_PORT: %v", err)
 	}
+
+	// Load OTP_PEPPER_FILE
+	loadPeppers()
+
+	// Parse TOTP_ENCRYPTION_KEY (base64-encoded 32 bytes)
+	if val := os.Getenv("TOTP_ENCRYPTION_KEY"); val != "" {
+		AppConfig.TOTPEncryptionKey, err = base64.StdEncoding.DecodeString(val)
+		if err != nil || len(AppConfig.TOTPEncryptionKey) != 32 {
+			log.Fatalf("Invalid TOTP_ENCRYPTION_KEY: must be 32 bytes, base64-encoded")
+		}
+	}
 }
 
 func getEnv(key, defaultVal string) string {
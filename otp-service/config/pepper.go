@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// MinPepperBytes is the shortest pepper accepted for HMAC-ing OTPs
+const MinPepperBytes = 32
+
+// loadPeppers reads OTP_PEPPER_FILE. Each non-empty, non-# line is "<key-id>:<base64 pepper>".
+// The active key signs new OTPs (OTP_PEPPER_KEY_ID, or the first key in the file);
+// the others are kept so sessions issued before a rotation can still be verified.
+func loadPeppers() {
+	path := getEnv("OTP_PEPPER_FILE", "")
+	if path == "" {
+		if AppConfig.AppEnv == "production" {
+			log.Fatalf("OTP_PEPPER_FILE is required in production")
+		}
+		// Development only: an ephemeral pepper, so OTPs do not survive a restart
+		pepper := make([]byte, MinPepperBytes)
+		if _, err := rand.Read(pepper); err != nil {
+			log.Fatalf("Failed to generate development OTP pepper: %v", err)
+		}
+		AppConfig.OTPPeppers = map[string][]byte{"dev": pepper}
+		AppConfig.OTPPepperKeyID = "dev"
+		log.Println("[WARN] OTP_PEPPER_FILE not set, using an ephemeral development pepper")
+		return
+	}
+
+	peppers, first, err := parsePepperFile(path)
+	if err != nil {
+		log.Fatalf("Invalid OTP_PEPPER_FILE: %v", err)
+	}
+
+	active := getEnv("OTP_PEPPER_KEY_ID", first)
+	if _, ok := peppers[active]; !ok {
+		log.Fatalf("Invalid OTP_PEPPER_KEY_ID: key %q not found in %s", active, path)
+	}
+
+	AppConfig.OTPPeppers = peppers
+	AppConfig.OTPPepperKeyID = active
+}
+
+func parsePepperFile(path string) (map[string][]byte, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	peppers := map[string][]byte{}
+	first := ""
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(line, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("line %d: expected <key-id>:<base64 pepper>", lineNo)
+		}
+		pepper, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(pepper) < MinPepperBytes {
+			return nil, "", fmt.Errorf("line %d: pepper must be at least %d bytes, base64-encoded", lineNo, MinPepperBytes)
+		}
+		if _, dup := peppers[id]; dup {
+			return nil, "", fmt.Errorf("line %d: duplicate key id %q", lineNo, id)
+		}
+
+		peppers[id] = pepper
+		if first == "" {
+			first = id
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	if len(peppers) == 0 {
+		return nil, "", fmt.Errorf("no keys found")
+	}
+	return peppers, first, nil
+}
@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"otp-service/config"
+	"otp-service/models"
+	"strings"
+	"time"
+)
+
+// Channel names accepted in requests, preferences and DELIVERY_FALLBACK_ORDER
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelVoice   = "voice"
+	ChannelWebhook = "webhook"
+)
+
+// Message is a single OTP or magic link to hand to a channel
+type Message struct {
+	// ID is unique per generated OTP; email-service uses it as the
+	// Idempotency-Key so a retried request is not delivered twice
+	ID     string
+	Email  string
+	Phone  string // E.164, required by SMS and voice
+	Mode   string // models.ModeCode or models.ModeLink
+	OTP    string // set for ModeCode
+	Link   string // set for ModeLink
+	Locale string // BCP 47 tag for localized emails, e.g. "fr-CA"
+}
+
+// DeliveryChannel sends OTPs and magic links to the user over one medium
+type DeliveryChannel interface {
+	Name() string
+	// CanDeliver reports whether msg carries what this channel needs (e.g. a phone number)
+	CanDeliver(msg Message) bool
+	// Send hands msg over and returns the provider's reference for it, or ""
+	// when the channel has none
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Status is what a provider reports about a sent message
+type Status struct {
+	State    string     `json:"state"`
+	Reason   string     `json:"reason,omitempty"`
+	Attempts int        `json:"attempts"`
+	SentAt   *time.Time `json:"sent_at,omitempty"`
+}
+
+// StatusReporter is implemented by channels that can look up a message after Send
+type StatusReporter interface {
+	Status(ctx context.Context, ref string) (*Status, error)
+}
+
+// Text renders msg as a short plain-text body for SMS-like channels
+func (m Message) Text() string {
+	if m.Mode == models.ModeLink {
+		return fmt.Sprintf("Your sign-in link: %s (expires in %d minutes)", m.Link, int(config.OTPTTL.Minutes()))
+	}
+	return fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", m.OTP, int(config.OTPTTL.Minutes()))
+}
+
+// SpokenText renders msg for text-to-speech, reading the code digit by digit
+func (m Message) SpokenText() string {
+	if m.Mode == models.ModeLink {
+		return "A sign-in link was requested for your account. Please check your messages."
+	}
+	digits := strings.Split(m.OTP, "")
+	return fmt.Sprintf("Your verification code is %s. Again, %s.", strings.Join(digits, ", "), strings.Join(digits, ", "))
+}
+
+// postJSON sends payload to url and treats any non-2xx status as a delivery failure.
+// A JSON response is decoded into out when it is not nil.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: config.DeliveryTimeout}
+}
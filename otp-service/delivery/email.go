@@ -0,0 +1,94 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"otp-service/models"
+)
+
+// EmailChannel delivers through email-service
+type EmailChannel struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewEmailChannel(baseURL string) *EmailChannel {
+	return &EmailChannel{baseURL: baseURL, client: newHTTPClient()}
+}
+
+func (ch *EmailChannel) Name() string { return ChannelEmail }
+
+func (ch *EmailChannel) CanDeliver(msg Message) bool { return msg.Email != "" }
+
+// emailSendAttempts is how often a request to email-service is tried. Retrying
+// is safe because every attempt carries the message's Idempotency-Key.
+const emailSendAttempts = 2
+
+// Send returns email-service's message ID, which Status looks up
+func (ch *EmailChannel) Send(ctx context.Context, msg Message) (string, error) {
+	url := ch.baseURL + "/send-otp"
+	payload := map[string]string{
+		"email":  msg.Email,
+		"otp":    msg.OTP,
+		"locale": msg.Locale,
+	}
+	if msg.Mode == models.ModeLink {
+		url = ch.baseURL + "/send-magic-link"
+		payload = map[string]string{
+			"email":  msg.Email,
+			"link":   msg.Link,
+			"locale": msg.Locale,
+		}
+	}
+
+	var headers map[string]string
+	if msg.ID != "" {
+		headers = map[string]string{"Idempotency-Key": msg.ID}
+	}
+
+	var resp struct {
+		MessageID string `json:"message_id"`
+	}
+	var err error
+	for attempt := 1; attempt <= emailSendAttempts; attempt++ {
+		if err = postJSON(ctx, ch.client, url, payload, headers, &resp); err == nil || headers == nil || ctx.Err() != nil {
+			return resp.MessageID, err
+		}
+	}
+	return "", err
+}
+
+// Status asks email-service where message ref is in its delivery lifecycle
+func (ch *EmailChannel) Status(ctx context.Context, ref string) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ch.baseURL+"/messages/"+url.PathEscape(ref), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUnknownMessage
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &status, nil
+}
+
+var (
+	_ DeliveryChannel = (*EmailChannel)(nil)
+	_ StatusReporter  = (*EmailChannel)(nil)
+)
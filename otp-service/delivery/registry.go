@@ -0,0 +1,115 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"otp-service/config"
+)
+
+var (
+	// ErrNoChannel is returned when no configured channel can reach the user
+	ErrNoChannel = errors.New("no delivery channel available")
+	// ErrNotTracked is returned for channels that cannot report on a sent message
+	ErrNotTracked = errors.New("delivery status not tracked for this channel")
+	// ErrUnknownMessage is returned when the provider has no record of a message
+	ErrUnknownMessage = errors.New("message not known to provider")
+)
+
+var channels = map[string]DeliveryChannel{}
+
+// InitChannels registers the channels enabled by configuration. In stub mode every
+// channel is replaced by a StubChannel so nothing leaves the machine.
+func InitChannels() {
+	channels = map[string]DeliveryChannel{}
+	cfg := config.AppConfig
+
+	if cfg.DeliveryMode == config.DeliveryModeStub {
+		for _, name := range []string{ChannelEmail, ChannelSMS, ChannelVoice, ChannelWebhook} {
+			Register(NewStubChannel(name, cfg.DeliveryStubFile))
+		}
+		log.Println("OTP delivery running in stub mode")
+		return
+	}
+
+	Register(NewEmailChannel(cfg.EmailServiceUrl))
+	if cfg.SMSProviderURL != "" {
+		Register(NewSMSChannel(cfg.SMSProviderURL, cfg.SMSProviderToken))
+	}
+	if cfg.VoiceProviderURL != "" {
+		Register(NewVoiceChannel(cfg.VoiceProviderURL, cfg.VoiceProviderToken))
+	}
+	if cfg.WebhookURL != "" {
+		Register(NewWebhookChannel(cfg.WebhookURL, cfg.WebhookSecret))
+	}
+}
+
+// Register adds or replaces a channel under its name
+func Register(ch DeliveryChannel) {
+	channels[ch.Name()] = ch
+}
+
+// Available reports whether a channel with this name is registered
+func Available(name string) bool {
+	_, ok := channels[name]
+	return ok
+}
+
+// Deliver sends msg over the preferred channel, falling back through
+// DELIVERY_FALLBACK_ORDER when it is unavailable or fails. Channels that
+// allowed rejects are never tried, so a fallback cannot reach a medium the
+// user did not enable; a nil allowed permits every channel.
+// It returns the name of the channel that delivered the message and that
+// channel's reference for it.
+func Deliver(ctx context.Context, preferred string, msg Message, allowed func(string) bool) (string, string, error) {
+	order := make([]string, 0, len(config.AppConfig.DeliveryFallbackOrder)+1)
+	if preferred != "" {
+		order = append(order, preferred)
+	}
+	for _, name := range config.AppConfig.DeliveryFallbackOrder {
+		if name != preferred {
+			order = append(order, name)
+		}
+	}
+
+	var lastErr error
+	for _, name := range order {
+		ch, ok := channels[name]
+		if !ok || !ch.CanDeliver(msg) || (allowed != nil && !allowed(name)) {
+			continue
+		}
+
+		ref, err := ch.Send(ctx, msg)
+		if err != nil {
+			log.Printf("[WARN] Delivery via %s failed: %v", name, err)
+			lastErr = err
+			continue
+		}
+		return name, ref, nil
+	}
+
+	if lastErr != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrNoChannel, lastErr)
+	}
+	return "", "", ErrNoChannel
+}
+
+// Send delivers msg over exactly the named channel, without fallback. It is
+// used where only that channel proves anything, such as verifying a phone number.
+func Send(ctx context.Context, name string, msg Message) (string, error) {
+	ch, ok := channels[name]
+	if !ok || !ch.CanDeliver(msg) {
+		return "", ErrNoChannel
+	}
+	return ch.Send(ctx, msg)
+}
+
+// Lookup asks the named channel what became of message ref
+func Lookup(ctx context.Context, channel, ref string) (*Status, error) {
+	reporter, ok := channels[channel].(StatusReporter)
+	if !ok || ref == "" {
+		return nil, ErrNotTracked
+	}
+	return reporter.Status(ctx, ref)
+}
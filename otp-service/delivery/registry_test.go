@@ -0,0 +1,105 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"otp-service/config"
+	"otp-service/models"
+	"testing"
+	"time"
+)
+
+// fakeChannel records how often it was asked to send and fails with err
+type fakeChannel struct {
+	name string
+	err  error
+	sent int
+}
+
+func (ch *fakeChannel) Name() string                { return ch.name }
+func (ch *fakeChannel) CanDeliver(msg Message) bool { return true }
+
+func (ch *fakeChannel) Send(ctx context.Context, msg Message) (string, error) {
+	ch.sent++
+	return "", ch.err
+}
+
+// useChannels swaps the registry and fallback order for the duration of a test
+func useChannels(t *testing.T, order []string, list ...DeliveryChannel) {
+	t.Helper()
+	savedChannels, savedOrder := channels, config.AppConfig.DeliveryFallbackOrder
+	t.Cleanup(func() {
+		channels = savedChannels
+		config.AppConfig.DeliveryFallbackOrder = savedOrder
+	})
+	channels = map[string]DeliveryChannel{}
+	for _, ch := range list {
+		Register(ch)
+	}
+	config.AppConfig.DeliveryFallbackOrder = order
+}
+
+func TestDeliverFallbackSkipsWebhookNotChosen(t *testing.T) {
+	hits := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer hook.Close()
+
+	email := &fakeChannel{name: ChannelEmail, err: errors.New("email-service down")}
+	useChannels(t, []string{ChannelEmail, ChannelSMS, ChannelVoice, ChannelWebhook},
+		email, NewWebhookChannel(hook.URL, "secret"))
+
+	pref := &models.DeliveryPreference{Email: "user@example.com", Channel: ChannelEmail}
+	msg := Message{ID: "1", Email: "user@example.com", Mode: models.ModeCode, OTP: "123456"}
+
+	_, _, err := Deliver(context.Background(), pref.Channel, msg, pref.Allows)
+	if !errors.Is(err, ErrNoChannel) {
+		t.Fatalf("err = %v, want ErrNoChannel", err)
+	}
+	if email.sent != 1 {
+		t.Errorf("email tried %d times, want 1", email.sent)
+	}
+	if hits != 0 {
+		t.Errorf("webhook received %d requests from a user who never chose it", hits)
+	}
+}
+
+func TestDeliverFallbackFollowsPreference(t *testing.T) {
+	verified := time.Now()
+	tests := []struct {
+		name string
+		pref *models.DeliveryPreference
+		want string
+	}{
+		{"no preference", nil, ""},
+		{"verified phone", &models.DeliveryPreference{Channel: ChannelEmail, Phone: "+15550100", PhoneVerifiedAt: &verified}, ChannelSMS},
+		{"unverified phone", &models.DeliveryPreference{Channel: ChannelEmail, Phone: "+15550100"}, ""},
+		{"webhook chosen", &models.DeliveryPreference{Channel: ChannelWebhook}, ChannelWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sms := &fakeChannel{name: ChannelSMS}
+			webhook := &fakeChannel{name: ChannelWebhook}
+			useChannels(t, []string{ChannelEmail, ChannelSMS, ChannelWebhook},
+				&fakeChannel{name: ChannelEmail, err: errors.New("email-service down")}, sms, webhook)
+
+			used, _, err := Deliver(context.Background(), ChannelEmail, Message{Email: "user@example.com"}, tt.pref.Allows)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoChannel) {
+					t.Errorf("delivered via %q, want ErrNoChannel", used)
+				}
+			} else if err != nil || used != tt.want {
+				t.Errorf("delivered via %q (err %v), want %s", used, err, tt.want)
+			}
+			if tt.want != ChannelSMS && sms.sent != 0 {
+				t.Errorf("sms tried %d times", sms.sent)
+			}
+			if tt.want != ChannelWebhook && webhook.sent != 0 {
+				t.Errorf("webhook tried %d times", webhook.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+)
+
+// SMSChannel posts {to, body} to a generic SMS provider HTTP API
+type SMSChannel struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewSMSChannel(url, token string) *SMSChannel {
+	return &SMSChannel{url: url, token: token, client: newHTTPClient()}
+}
+
+func (ch *SMSChannel) Name() string { return ChannelSMS }
+
+func (ch *SMSChannel) CanDeliver(msg Message) bool { return msg.Phone != "" }
+
+func (ch *SMSChannel) Send(ctx context.Context, msg Message) (string, error) {
+	return "", postJSON(ctx, ch.client, ch.url, map[string]string{
+		"to":   msg.Phone,
+		"body": msg.Text(),
+	}, bearerHeader(ch.token), nil)
+}
+
+// VoiceChannel posts {to, message} to a text-to-speech call provider HTTP API.
+// Magic links cannot be read out, so it only announces them.
+type VoiceChannel struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewVoiceChannel(url, token string) *VoiceChannel {
+	return &VoiceChannel{url: url, token: token, client: newHTTPClient()}
+}
+
+func (ch *VoiceChannel) Name() string { return ChannelVoice }
+
+func (ch *VoiceChannel) CanDeliver(msg Message) bool { return msg.Phone != "" && msg.OTP != "" }
+
+func (ch *VoiceChannel) Send(ctx context.Context, msg Message) (string, error) {
+	return "", postJSON(ctx, ch.client, ch.url, map[string]string{
+		"to":      msg.Phone,
+		"message": msg.SpokenText(),
+	}, bearerHeader(ch.token), nil)
+}
+
+func bearerHeader(token string) map[string]string {
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+var (
+	_ DeliveryChannel = (*SMSChannel)(nil)
+	_ DeliveryChannel = (*VoiceChannel)(nil)
+)
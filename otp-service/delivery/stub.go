@@ -0,0 +1,59 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// StubChannel stands in for a real channel in development and tests. Instead of
+// contacting a provider it prints the message to the console or appends it to a file.
+type StubChannel struct {
+	name string
+	path string // empty writes to the console
+	mu   sync.Mutex
+}
+
+func NewStubChannel(name, path string) *StubChannel {
+	return &StubChannel{name: name, path: path}
+}
+
+func (ch *StubChannel) Name() string { return ch.name }
+
+func (ch *StubChannel) CanDeliver(msg Message) bool {
+	switch ch.name {
+	case ChannelSMS:
+		return msg.Phone != ""
+	case ChannelVoice:
+		return msg.Phone != "" && msg.OTP != ""
+	default:
+		return true
+	}
+}
+
+func (ch *StubChannel) Send(ctx context.Context, msg Message) (string, error) {
+	line := fmt.Sprintf("%s [STUB:%s] email=%s phone=%s mode=%s otp=%s link=%s\n",
+		time.Now().UTC().Format(time.RFC3339), ch.name, msg.Email, msg.Phone, msg.Mode, msg.OTP, msg.Link)
+
+	if ch.path == "" {
+		log.Print(line)
+		return "", nil
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	f, err := os.OpenFile(ch.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open stub delivery file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(line)
+	return "", err
+}
+
+var _ DeliveryChannel = (*StubChannel)(nil)
@@ -0,0 +1,67 @@
+package delivery
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookChannel posts the message as JSON to an arbitrary URL. When a secret is set
+// the body is signed with HMAC-SHA256 in the X-Signature header ("sha256=<hex>").
+type WebhookChannel struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Channel string    `json:"channel"`
+	Email   string    `json:"email"`
+	Phone   string    `json:"phone,omitempty"`
+	Mode    string    `json:"mode"`
+	OTP     string    `json:"otp,omitempty"`
+	Link    string    `json:"link,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+func NewWebhookChannel(url, secret string) *WebhookChannel {
+	return &WebhookChannel{url: url, secret: secret, client: newHTTPClient()}
+}
+
+func (ch *WebhookChannel) Name() string { return ChannelWebhook }
+
+func (ch *WebhookChannel) CanDeliver(msg Message) bool { return true }
+
+func (ch *WebhookChannel) Send(ctx context.Context, msg Message) (string, error) {
+	payload := webhookPayload{
+		Channel: ChannelWebhook,
+		Email:   msg.Email,
+		Phone:   msg.Phone,
+		Mode:    msg.Mode,
+		OTP:     msg.OTP,
+		Link:    msg.Link,
+		SentAt:  time.Now().UTC(),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	var headers map[string]string
+	if ch.secret != "" {
+		mac := hmac.New(sha256.New, []byte(ch.secret))
+		mac.Write(body)
+		headers = map[string]string{"X-Signature": "sha256=" + hex.EncodeToString(mac.Sum(nil))}
+	}
+
+	// Send the exact bytes that were signed
+	return "", postJSON(ctx, ch.client, ch.url, json.RawMessage(body), headers, nil)
+}
+
+var _ DeliveryChannel = (*WebhookChannel)(nil)
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"otp-service/config"
+	"otp-service/delivery"
+	"otp-service/models"
+	"otp-service/redis"
+	"otp-service/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetDeliveryPreferenceHandler stores the channel a user wants OTPs sent to. A new
+// phone number for SMS or voice is not saved until the code sent to it is confirmed
+// with VerifyDeliveryPreferenceHandler, so OTPs only ever go to a phone the user owns.
+func SetDeliveryPreferenceHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeDeliveryPreference}
+
+	var req models.DeliveryPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid delivery preference"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid email, channel (email, sms, voice, webhook) and E.164 phone are required"})
+		return
+	}
+	params.Email = req.Email
+
+	if !delivery.Available(req.Channel) {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Channel not configured: " + req.Channel
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Delivery channel is not available: " + req.Channel})
+		return
+	}
+
+	current, err := findDeliveryPreference(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load delivery preference"})
+		return
+	}
+	verified := current.VerifiedPhone()
+
+	phoneChannel := req.Channel == delivery.ChannelSMS || req.Channel == delivery.ChannelVoice
+	if !phoneChannel && req.Phone != "" && req.Phone != verified {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Phone given for " + req.Channel
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A phone number can only be set with channel sms or voice"})
+		return
+	}
+
+	phone := req.Phone
+	if phoneChannel && phone == "" {
+		phone = verified
+	}
+	if phoneChannel && phone == "" {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Phone required for " + req.Channel
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Phone number is required for " + req.Channel})
+		return
+	}
+
+	if phoneChannel && phone != verified {
+		startPhoneVerification(c, logger, params, req.Channel, phone)
+		return
+	}
+
+	if err := saveDeliveryPreference(models.DeliveryPreference{Email: req.Email, Channel: req.Channel}, "channel", "updated_at"); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Failed to store delivery preference"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store delivery preference"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "Delivery preference set to " + req.Channel
+	logger.LogOTPEvent(c, params)
+
+	c.JSON(http.StatusOK, gin.H{"channel": req.Channel, "phone": verified})
+}
+
+// startPhoneVerification sends a code to phone over channel itself, with no
+// fallback, and parks the preference in Redis until the code comes back
+func startPhoneVerification(c *gin.Context, logger *utils.Logger, params utils.OTPEventParams, channel, phone string) {
+	code := utils.GenerateSecureOTP(config.OTPLength)
+	hash, keyID, err := utils.HashOTP(code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash verification code"})
+		return
+	}
+
+	pending := models.PhoneVerification{Email: params.Email, Channel: channel, Phone: phone, CodeHash: hash, KeyID: keyID}
+	if err := redis.StorePhoneVerification(pending, config.OTPTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store phone verification"})
+		return
+	}
+
+	if config.AppConfig.AppEnv != "production" {
+		log.Printf("[DEVELOPMENT] Phone verification code for %s: %s", phone, code)
+	}
+
+	msg := delivery.Message{ID: utils.GenerateSessionID(), Email: params.Email, Phone: phone, Mode: models.ModeCode, OTP: code}
+	if _, err := delivery.Send(c.Request.Context(), channel, msg); err != nil {
+		redis.DeletePhoneVerification(params.Email)
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Failed to send phone verification via " + channel + ": " + err.Error()
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification code to phone"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "Phone verification sent via " + channel
+	logger.LogOTPEvent(c, params)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"channel": channel,
+		"phone":   phone,
+		"message": "Enter the code sent to this phone to finish setting the preference",
+	})
+}
+
+// VerifyDeliveryPreferenceHandler confirms a pending phone number with the code sent
+// to it and only then saves the SMS or voice preference
+func VerifyDeliveryPreferenceHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeDeliveryPreferenceVerify}
+
+	var req models.DeliveryPreferenceVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid request payload"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid email and code are required"})
+		return
+	}
+	params.Email = req.Email
+
+	pending, err := redis.GetPhoneVerification(req.Email)
+	if err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "No pending phone verification"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending phone verification, or it expired"})
+		return
+	}
+
+	if !utils.CompareOTP(pending.CodeHash, pending.KeyID, req.Code) {
+		params.Attempts = pending.Attempts + 1
+		if params.Attempts >= config.MaxAttempts {
+			redis.DeletePhoneVerification(req.Email)
+			params.EventStatus = models.EventStatusBlocked
+			params.Msg = "Maximum phone verification attempts exceeded"
+			logger.LogOTPEvent(c, params)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Maximum verification attempts exceeded"})
+			return
+		}
+		if err := redis.IncrementPhoneVerificationAttempts(pending); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+			return
+		}
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid phone verification code"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	redis.DeletePhoneVerification(req.Email)
+
+	now := time.Now()
+	pref := models.DeliveryPreference{Email: req.Email, Channel: pending.Channel, Phone: pending.Phone, PhoneVerifiedAt: &now}
+	if err := saveDeliveryPreference(pref, "channel", "phone", "phone_verified_at", "updated_at"); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Failed to store delivery preference"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store delivery preference"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "Phone verified, delivery preference set to " + pending.Channel
+	logger.LogOTPEvent(c, params)
+
+	c.JSON(http.StatusOK, gin.H{"channel": pending.Channel, "phone": pending.Phone})
+}
+
+// saveDeliveryPreference inserts pref, or updates only columns of the existing row
+func saveDeliveryPreference(pref models.DeliveryPreference, columns ...string) error {
+	return config.UserDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&pref).Error
+}
+
+// GetDeliveryPreferenceHandler returns the stored delivery preference for ?email=
+func GetDeliveryPreferenceHandler(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email query parameter is required"})
+		return
+	}
+
+	pref, err := findDeliveryPreference(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load delivery preference"})
+		return
+	}
+	if pref == nil {
+		c.JSON(http.StatusOK, gin.H{"channel": delivery.ChannelEmail})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channel": pref.Channel, "phone": pref.VerifiedPhone()})
+}
+
+// DeliveryStatusHandler reports whether the last OTP or magic link sent for the
+// X-Session-ID session has reached the user, as far as the channel can tell
+func DeliveryStatusHandler(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing X-Session-ID header"})
+		return
+	}
+
+	session, err := redis.GetSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or expired"})
+		return
+	}
+	if session.DeliveryChannel == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nothing has been sent for this session"})
+		return
+	}
+
+	status, err := delivery.Lookup(c.Request.Context(), session.DeliveryChannel, session.DeliveryRef)
+	switch {
+	case errors.Is(err, delivery.ErrNotTracked), errors.Is(err, delivery.ErrUnknownMessage):
+		c.JSON(http.StatusOK, gin.H{"channel": session.DeliveryChannel, "state": "unknown"})
+	case err != nil:
+		log.Printf("[WARN] Failed to look up delivery status: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to look up delivery status"})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"channel":  session.DeliveryChannel,
+			"state":    status.State,
+			"reason":   status.Reason,
+			"attempts": status.Attempts,
+			"sent_at":  status.SentAt,
+		})
+	}
+}
+
+// findDeliveryPreference returns nil (and no error) when the user has not set one
+func findDeliveryPreference(email string) (*models.DeliveryPreference, error) {
+	var pref models.DeliveryPreference
+	err := config.UserDB.Where("email = ?", email).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
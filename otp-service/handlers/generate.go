@@ -9,8 +9,8 @@ import (
 	"otp-service/redis"
 	"otp-service/utils"
 	"time"
-	"bytes"
-	"encoding/json"
+	"net/url"
+	"otp-service/delivery"
 )
 
 func GenerateOTPHandler(c *gin.Context) {
@@ -83,13 +83,59 @@ func GenerateOTPHandler(c *gin.Context) {
 		params.EventType = models.EventTypeResend
 		params.EventStatus = models.EventStatusSuccess
 		params.Msg = "OTP resent successfully"
-		params.OTPHash = session.OTPHash
 		logger.LogOTPEvent(c, params)
 	}
 
-	// Generate and hash OTP
-	otp := utils.GenerateSecureOTP(config.OTPLength)
-	hashedOTP, err := utils.HashOTP(otp)
+	mode := req.Mode
+	if mode == "" {
+		mode = models.ModeCode
+	}
+
+	// Authenticator logins need no delivery: the session just waits for a TOTP code
+	if mode == models.ModeTOTP {
+		enrollment, err := findTOTPEnrollment(email)
+		if !totpEnabled() || err != nil || enrollment == nil || !enrollment.Confirmed() {
+			params.EventStatus = models.EventStatusFailed
+			params.Msg = "TOTP not enrolled"
+			logger.LogOTPEvent(c, params)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "TOTP is not enrolled for this account"})
+			return
+		}
+
+		session.OTPHash = ""
+		session.KeyID = ""
+		session.DeliveryChannel = ""
+		session.DeliveryRef = ""
+		session.Mode = mode
+		session.CreatedAt = time.Now()
+		if err := redis.StoreSession(*session, config.OTPTTL); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store session in Redis"})
+			return
+		}
+
+		params.EventType = models.EventTypeGenerate
+		params.EventStatus = models.EventStatusSuccess
+		params.Msg = "TOTP login session created"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusOK, gin.H{"success": "Enter the code from your authenticator app"})
+		return
+	}
+
+	// Generate and hash the secret: a numeric OTP or a single-use magic-link token
+	var secret string
+	if mode == models.ModeLink {
+		secret, err = utils.GenerateMagicLinkToken(config.MagicLinkTokenBytes)
+		if err != nil {
+			params.EventStatus = models.EventStatusFailed
+			params.Msg = "Failed to generate magic link token"
+			logger.LogOTPEvent(c, params)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate magic link"})
+			return
+		}
+	} else {
+		secret = utils.GenerateSecureOTP(config.OTPLength)
+	}
+	hashedOTP, keyID, err := utils.HashOTP(secret)
 	if err != nil {
 		params.EventStatus = models.EventStatusFailed
 		params.Msg = "Failed to hash OTP"
@@ -99,11 +145,13 @@ func GenerateOTPHandler(c *gin.Context) {
 	}
 
 	if config.AppConfig.AppEnv != "production" {
-		log.Printf("[DEVELOPMENT] Generated OTP for %s: %s", email, otp)
+		log.Printf("[DEVELOPMENT] Generated %s secret for %s: %s", mode, email, secret)
 	}
 
 	// Update session
 	session.OTPHash = hashedOTP
+	session.KeyID = keyID
+	session.Mode = mode
 	session.CreatedAt = time.Now()
 
 	if err := redis.StoreSession(*session, config.OTPTTL); err != nil {
@@ -111,37 +159,61 @@ func GenerateOTPHandler(c *gin.Context) {
 		return
 	}
 
-	// Send OTP to email-service
-	emailServiceURL := config.AppConfig.EmailServiceUrl + "/send-otp"
-	emailReqBody, err := json.Marshal(map[string]string{
-		"email": email,
-		"otp":   otp,
-	})
+	// Deliver over the requested channel, else the user's preference, falling back as configured.
+	// Every channel tried, fallbacks included, must be allowed by the preference, and
+	// SMS and voice only ever go to the phone the user verified for it.
+	pref, err := findDeliveryPreference(email)
 	if err != nil {
+		log.Printf("[WARN] Failed to load delivery preference: %v", err)
+	}
+	channel := req.Channel
+	if channel != "" && !pref.Allows(channel) {
 		redis.DeleteSession(sessionID)
 		params.EventStatus = models.EventStatusFailed
-		params.Msg = "Failed to marshal email request"
+		params.Msg = "Channel not allowed by delivery preference: " + channel
 		logger.LogOTPEvent(c, params)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Delivery channel is not enabled for this account: " + channel})
 		return
 	}
+	if channel == "" && pref != nil {
+		channel = pref.Channel
+	}
+
+	msg := delivery.Message{ID: utils.GenerateSessionID(), Email: email, Phone: pref.VerifiedPhone(), Mode: mode, Locale: req.Locale}
+	if mode == models.ModeLink {
+		msg.Link = config.AppConfig.MagicLinkBaseURL + "?token=" + url.QueryEscape(secret)
+	} else {
+		msg.OTP = secret
+	}
 
-	resp, err := http.Post(emailServiceURL, "application/json", bytes.NewBuffer(emailReqBody))
-	if err != nil || resp.StatusCode != http.StatusOK {
+	usedChannel, ref, err := delivery.Deliver(c.Request.Context(), channel, msg, pref.Allows)
+	if err != nil {
 		redis.DeleteSession(sessionID)
 		params.EventStatus = models.EventStatusFailed
-		params.Msg = "Failed to deliver OTP via email service"
+		params.Msg = "Failed to deliver OTP: " + err.Error()
 		logger.LogOTPEvent(c, params)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deliver OTP"})
 		return
 	}
 
+	session.DeliveryChannel = usedChannel
+	session.DeliveryRef = ref
+	if err := redis.StoreSession(*session, config.OTPTTL); err != nil {
+		log.Printf("[WARN] Failed to record delivery reference: %v", err)
+	}
+
 	params.EventType = models.EventTypeGenerate
 	params.EventStatus = models.EventStatusSuccess
-	params.Msg = "OTP generated and sent successfully"
-	params.OTPHash = hashedOTP
+	params.Msg = "OTP generated and sent via " + usedChannel
+	if mode == models.ModeLink {
+		params.Msg = "Magic link generated and sent via " + usedChannel
+	}
 	params.Resends = session.Resends
 	logger.LogOTPEvent(c, params)
 
-	c.JSON(http.StatusOK, gin.H{"success": "OTP sent successfully"})
+	if mode == models.ModeLink {
+		c.JSON(http.StatusOK, gin.H{"success": "Magic link sent successfully", "channel": usedChannel})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "OTP sent successfully", "channel": usedChannel})
 }
\ No newline at end of file
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"otp-service/config"
+	"otp-service/models"
+	"otp-service/redis"
+	"otp-service/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GenerateRecoveryCodesHandler replaces all recovery codes of a user with a fresh set.
+// The plaintext codes are returned once and never stored.
+func GenerateRecoveryCodesHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeRecoveryGenerate}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Valid email is required"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid email is required"})
+		return
+	}
+	params.Email = req.Email
+
+	codes := make([]string, 0, config.RecoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, config.RecoveryCodeCount)
+	for i := 0; i < config.RecoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode(config.RecoveryCodeLength)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+			return
+		}
+		normalized := utils.NormalizeRecoveryCode(code)
+		hash, err := utils.HashRecoveryCode(normalized)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash recovery codes"})
+			return
+		}
+		lookup, err := utils.RecoveryLookupHash(normalized)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash recovery codes"})
+			return
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{Email: req.Email, LookupHash: lookup, CodeHash: hash})
+	}
+
+	// Regenerating invalidates every previous code, used or not
+	err := config.UserDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("email = ?", req.Email).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Failed to store recovery codes"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store recovery codes"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "Recovery codes generated"
+	logger.LogOTPEvent(c, params)
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+		"message":        "Store these codes somewhere safe. They will not be shown again.",
+	})
+}
+
+// VerifyRecoveryCodeHandler accepts a recovery code in place of an OTP at the verify step
+func VerifyRecoveryCodeHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		logEventAndRespond(c, logger, "Missing X-Session-ID header", models.EventTypeRecoveryUse, "failure", "", 0, 0, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logEventAndRespond(c, logger, "Invalid request payload", models.EventTypeRecoveryUse, "failure", "", 0, 0, http.StatusBadRequest)
+		return
+	}
+
+	session, err := redis.GetSession(sessionID)
+	if err != nil {
+		logEventAndRespond(c, logger, "Session expired or invalid", models.EventTypeRecoveryUse, "failure", "", 0, 0, http.StatusUnauthorized)
+		return
+	}
+
+	if session.Email != req.Email {
+		logEventAndRespond(c, logger, "Email does not match session", models.EventTypeRecoveryUse, "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
+		return
+	}
+
+	used, err := consumeRecoveryCode(session.Email, utils.NormalizeRecoveryCode(req.Code))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify recovery code"})
+		return
+	}
+
+	if !used {
+		if err := redis.IncrementReattempts(sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+			return
+		}
+
+		session.Attempts++
+		if session.Attempts >= config.MaxAttempts {
+			redis.DeleteSession(sessionID)
+			logEventAndRespond(c, logger, "Maximum verification attempts exceeded", models.EventTypeRecoveryUse, "failure", session.Email, session.Attempts, session.Resends, http.StatusTooManyRequests)
+			return
+		}
+
+		logEventAndRespond(c, logger, "Invalid recovery code", models.EventTypeRecoveryUse, "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
+		return
+	}
+
+	redis.DeleteSession(sessionID)
+
+	var remaining int64
+	if err := config.UserDB.Model(&models.RecoveryCode{}).Where("email = ? AND used_at IS NULL", session.Email).Count(&remaining).Error; err != nil {
+		log.Printf("[WARN] Failed to count remaining recovery codes: %v", err)
+	} else if remaining <= config.RecoveryCodesLowThreshold {
+		go notifyRecoveryCodesLow(session.Email, remaining)
+	}
+
+	logEventAndRespond(c, logger, "Recovery code accepted", models.EventTypeRecoveryUse, "success", session.Email, session.Attempts, session.Resends, http.StatusOK)
+}
+
+// consumeRecoveryCode marks the matching unused code as used. The row is found by its
+// lookup HMAC, so a wrong guess never costs more than one bcrypt comparison. The
+// conditional update makes sure a code can only be redeemed once, even by concurrent requests.
+func consumeRecoveryCode(email, code string) (bool, error) {
+	lookups := utils.RecoveryLookupHashes(code)
+	if len(lookups) == 0 {
+		return false, nil
+	}
+
+	var candidate models.RecoveryCode
+	err := config.UserDB.Where("email = ? AND lookup_hash IN ? AND used_at IS NULL", email, lookups).
+		Take(&candidate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !utils.CompareRecoveryCode(candidate.CodeHash, code) {
+		return false, nil
+	}
+	result := config.UserDB.Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", candidate.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// notifyRecoveryCodesLow asks email-service to warn the user that few recovery codes are left
+func notifyRecoveryCodesLow(email string, remaining int64) {
+	body, err := json.Marshal(map[string]interface{}{
+		"email":     email,
+		"remaining": remaining,
+	})
+	if err != nil {
+		return
+	}
+
+	resp, err := http.Post(config.AppConfig.EmailServiceUrl+"/send-recovery-codes-low", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.Printf("[WARN] Failed to send low recovery codes notice: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("[WARN] Email service rejected low recovery codes notice: status %d", resp.StatusCode)
+	}
+}
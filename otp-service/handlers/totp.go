@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"otp-service/config"
+	"otp-service/models"
+	"otp-service/redis"
+	"otp-service/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type totpEnrollRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type totpCodeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollHandler creates (or replaces) a pending authenticator enrollment and
+// returns the secret, its otpauth:// URI and a QR code PNG (base64)
+func TOTPEnrollHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeTOTPEnroll}
+
+	if !totpEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "TOTP is not configured"})
+		return
+	}
+
+	var req totpEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Valid email is required"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid email is required"})
+		return
+	}
+	params.Email = req.Email
+
+	enrollment, err := findTOTPEnrollment(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load TOTP enrollment"})
+		return
+	}
+	if enrollment != nil && enrollment.Confirmed() {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "TOTP already enrolled"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusConflict, gin.H{"error": "TOTP already enrolled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate TOTP secret"})
+		return
+	}
+	ciphertext, err := utils.EncryptSecret(config.AppConfig.TOTPEncryptionKey, secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt TOTP secret"})
+		return
+	}
+
+	if enrollment == nil {
+		enrollment = &models.TOTPEnrollment{Email: req.Email}
+	}
+	enrollment.SecretCiphertext = ciphertext
+	enrollment.LastUsedStep = 0
+	if err := config.UserDB.Save(enrollment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store TOTP enrollment"})
+		return
+	}
+
+	uri := utils.TOTPProvisioningURI(config.AppConfig.TOTPIssuer, req.Email, secret)
+	png, err := utils.TOTPQRCodePNG(uri)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render QR code"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "TOTP enrollment started"
+	logger.LogOTPEvent(c, params)
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": uri,
+		"qr_png":      base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// TOTPConfirmHandler activates a pending enrollment once the user submits a first valid code
+func TOTPConfirmHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeTOTPConfirm}
+
+	enrollment, req, ok := bindTOTPCodeRequest(c, logger, params)
+	if !ok {
+		return
+	}
+	params.Email = req.Email
+
+	if enrollment.Confirmed() {
+		c.JSON(http.StatusConflict, gin.H{"error": "TOTP already enrolled"})
+		return
+	}
+
+	valid, err := verifyTOTPCode(enrollment, req.Code)
+	if respondTOTPLocked(c, logger, params, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify TOTP code"})
+		return
+	}
+	if !valid {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid TOTP code"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid TOTP code"})
+		return
+	}
+
+	now := time.Now()
+	if err := config.UserDB.Model(enrollment).Update("confirmed_at", &now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm TOTP enrollment"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "TOTP enrollment confirmed"
+	logger.LogOTPEvent(c, params)
+	c.JSON(http.StatusOK, gin.H{"message": "TOTP enrollment confirmed"})
+}
+
+// TOTPVerifyHandler checks an authenticator code for a confirmed enrollment (step-up authentication)
+func TOTPVerifyHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+	params := utils.OTPEventParams{EventType: models.EventTypeTOTPVerify}
+
+	enrollment, req, ok := bindTOTPCodeRequest(c, logger, params)
+	if !ok {
+		return
+	}
+	params.Email = req.Email
+
+	if !enrollment.Confirmed() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "TOTP not enrolled"})
+		return
+	}
+
+	valid, err := verifyTOTPCode(enrollment, req.Code)
+	if respondTOTPLocked(c, logger, params, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify TOTP code"})
+		return
+	}
+	if !valid {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid TOTP code"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid TOTP code"})
+		return
+	}
+
+	params.EventStatus = models.EventStatusSuccess
+	params.Msg = "TOTP code verified successfully"
+	logger.LogOTPEvent(c, params)
+	c.JSON(http.StatusOK, gin.H{"message": "TOTP code verified successfully"})
+}
+
+// TOTPStatusHandler reports whether the ?email= user has a confirmed
+// authenticator enrollment, so callers know whether step-up is possible
+func TOTPStatusHandler(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email query parameter is required"})
+		return
+	}
+	if !totpEnabled() {
+		c.JSON(http.StatusOK, gin.H{"enrolled": false})
+		return
+	}
+
+	enrollment, err := findTOTPEnrollment(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load TOTP enrollment"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enrolled": enrollment != nil && enrollment.Confirmed()})
+}
+
+// bindTOTPCodeRequest parses an {email, code} body and loads the matching enrollment,
+// writing the error response itself when it returns false
+func bindTOTPCodeRequest(c *gin.Context, logger *utils.Logger, params utils.OTPEventParams) (*models.TOTPEnrollment, totpCodeRequest, bool) {
+	var req totpCodeRequest
+
+	if !totpEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "TOTP is not configured"})
+		return nil, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		params.EventStatus = models.EventStatusFailed
+		params.Msg = "Invalid request payload"
+		logger.LogOTPEvent(c, params)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return nil, req, false
+	}
+
+	enrollment, err := findTOTPEnrollment(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load TOTP enrollment"})
+		return nil, req, false
+	}
+	if enrollment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "TOTP not enrolled"})
+		return nil, req, false
+	}
+	return enrollment, req, true
+}
+
+func totpEnabled() bool {
+	return len(config.AppConfig.TOTPEncryptionKey) > 0
+}
+
+// findTOTPEnrollment returns the enrollment for email, or nil when there is none
+func findTOTPEnrollment(email string) (*models.TOTPEnrollment, error) {
+	var enrollment models.TOTPEnrollment
+	err := config.UserDB.Where("email = ?", email).First(&enrollment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// totpLockedError is returned by verifyTOTPCode while a user is locked out
+type totpLockedError struct {
+	retryAfter time.Duration
+}
+
+func (e *totpLockedError) Error() string {
+	return fmt.Sprintf("too many failed TOTP codes, retry in %s", e.retryAfter.Round(time.Second))
+}
+
+// respondTOTPLocked answers 429 with Retry-After when err is a lockout and reports whether it was
+func respondTOTPLocked(c *gin.Context, logger *utils.Logger, params utils.OTPEventParams, err error) bool {
+	var locked *totpLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	params.EventStatus = models.EventStatusFailed
+	params.Msg = "TOTP locked out after too many failed codes"
+	logger.LogOTPEvent(c, params)
+	c.Header("Retry-After", strconv.Itoa(int(locked.retryAfter.Seconds()+0.5)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed codes, try again later"})
+	return true
+}
+
+// verifyTOTPCode validates code and atomically records its time step, so a code
+// (or an older one) can't be replayed even by concurrent requests. Every attempt
+// counts against the user's failure budget first; once it is spent the code is
+// not checked and a *totpLockedError is returned.
+func verifyTOTPCode(enrollment *models.TOTPEnrollment, code string) (bool, error) {
+	retryAfter, err := redis.ReserveTOTPAttempt(enrollment.Email, config.TOTPMaxFailures, config.TOTPFailureWindow, config.TOTPLockout)
+	if err != nil {
+		return false, err
+	}
+	if retryAfter > 0 {
+		return false, &totpLockedError{retryAfter: retryAfter}
+	}
+
+	secret, err := utils.DecryptSecret(config.AppConfig.TOTPEncryptionKey, enrollment.SecretCiphertext)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), enrollment.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+
+	result := config.UserDB.Model(&models.TOTPEnrollment{}).
+		Where("id = ? AND last_used_step < ?", enrollment.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		redis.ResetTOTPAttempts(enrollment.Email)
+	}
+	return result.RowsAffected == 1, nil
+}
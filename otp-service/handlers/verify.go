@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"otp-service/config"
+	"otp-service/models"
 	"otp-service/redis"
 	"otp-service/utils"
 
@@ -14,7 +15,7 @@ func VerifyOTPHandler(c *gin.Context) {
 
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		logEventAndRespond(c, logger, "Missing X-Session-ID header", "verify", "failure", "", "", 0, 0, http.StatusBadRequest)
+		logEventAndRespond(c, logger, "Missing X-Session-ID header", "verify", "failure", "", 0, 0, http.StatusBadRequest)
 		return
 	}
 
@@ -23,22 +24,46 @@ func VerifyOTPHandler(c *gin.Context) {
 		OTP   string `json:"otp" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logEventAndRespond(c, logger, "Invalid request payload", "verify", "failure", "", "", 0, 0, http.StatusBadRequest)
+		logEventAndRespond(c, logger, "Invalid request payload", "verify", "failure", "", 0, 0, http.StatusBadRequest)
 		return
 	}
 
 	session, err := redis.GetSession(sessionID)
 	if err != nil {
-		logEventAndRespond(c, logger, "Session expired or invalid", "verify", "failure", "", "", 0, 0, http.StatusUnauthorized)
+		logEventAndRespond(c, logger, "Session expired or invalid", "verify", "failure", "", 0, 0, http.StatusUnauthorized)
+		return
+	}
+
+	if session.Mode == models.ModeLink {
+		logEventAndRespond(c, logger, "Session expects magic link verification", "verify", "failure", session.Email, session.Attempts, session.Resends, http.StatusBadRequest)
 		return
 	}
 
 	if session.Email != req.Email {
-		logEventAndRespond(c, logger, "Email does not match session", "verify", "failure", session.Email, session.OTPHash, session.Attempts, session.Resends, http.StatusUnauthorized)
+		logEventAndRespond(c, logger, "Email does not match session", "verify", "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
 		return
 	}
 
-	if !utils.CompareOTP(session.OTPHash, req.OTP) {
+	var valid bool
+	if session.Mode == models.ModeTOTP {
+		enrollment, err := findTOTPEnrollment(session.Email)
+		if err != nil || enrollment == nil || !totpEnabled() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load TOTP enrollment"})
+			return
+		}
+		valid, err = verifyTOTPCode(enrollment, req.OTP)
+		if respondTOTPLocked(c, logger, utils.OTPEventParams{SessionID: sessionID, EventType: "verify", Email: session.Email}, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify TOTP code"})
+			return
+		}
+	} else {
+		valid = utils.CompareOTP(session.OTPHash, session.KeyID, req.OTP)
+	}
+
+	if !valid {
 		if err := redis.IncrementReattempts(sessionID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
 			return
@@ -47,22 +72,22 @@ func VerifyOTPHandler(c *gin.Context) {
 		session.Attempts++
 		if session.Attempts >= config.MaxAttempts {
 			redis.DeleteSession(sessionID)
-			logEventAndRespond(c, logger, "Maximum verification attempts exceeded", "verify", "failure", session.Email, session.OTPHash, session.Attempts, session.Resends, http.StatusTooManyRequests)
+			logEventAndRespond(c, logger, "Maximum verification attempts exceeded", "verify", "failure", session.Email, session.Attempts, session.Resends, http.StatusTooManyRequests)
 			return
 		}
 
-		logEventAndRespond(c, logger, "Invalid OTP", "verify", "failure", session.Email, session.OTPHash, session.Attempts, session.Resends, http.StatusUnauthorized)
+		logEventAndRespond(c, logger, "Invalid OTP", "verify", "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
 		return
 	}
 
 	redis.DeleteSession(sessionID)
-	logEventAndRespond(c, logger, "OTP verified successfully", "verify", "success", session.Email, session.OTPHash, session.Attempts, session.Resends, http.StatusOK)
+	logEventAndRespond(c, logger, "OTP verified successfully", "verify", "success", session.Email, session.Attempts, session.Resends, http.StatusOK)
 }
 
 func logEventAndRespond(
 	c *gin.Context,
 	logger *utils.Logger,
-	msg, eventType, status, email, otpHash string,
+	msg, eventType, status, email string,
 	attempts, resends int,
 	httpStatus int,
 ) {
@@ -71,7 +96,6 @@ func logEventAndRespond(
 		EventType:   eventType,
 		EventStatus: status,
 		Email:       email,
-		OTPHash:     otpHash,
 		Attempts:    attempts,
 		Resends:     resends,
 		Msg:         msg,
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"otp-service/config"
+	"otp-service/models"
+	"otp-service/redis"
+	"otp-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMagicLinkHandler verifies a single-use magic-link token bound to the X-Session-ID session
+func VerifyMagicLinkHandler(c *gin.Context) {
+	logger := utils.NewLogger()
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		logEventAndRespond(c, logger, "Missing X-Session-ID header", models.EventTypeVerifyLink, "failure", "", 0, 0, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logEventAndRespond(c, logger, "Invalid request payload", models.EventTypeVerifyLink, "failure", "", 0, 0, http.StatusBadRequest)
+		return
+	}
+
+	session, err := redis.GetSession(sessionID)
+	if err != nil {
+		logEventAndRespond(c, logger, "Session expired or invalid", models.EventTypeVerifyLink, "failure", "", 0, 0, http.StatusUnauthorized)
+		return
+	}
+
+	if session.Mode != models.ModeLink {
+		logEventAndRespond(c, logger, "Session was not issued a magic link", models.EventTypeVerifyLink, "failure", session.Email, session.Attempts, session.Resends, http.StatusBadRequest)
+		return
+	}
+
+	if session.Email != req.Email {
+		logEventAndRespond(c, logger, "Email does not match session", models.EventTypeVerifyLink, "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
+		return
+	}
+
+	if !utils.CompareOTP(session.OTPHash, session.KeyID, req.Token) {
+		if err := redis.IncrementReattempts(sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+			return
+		}
+
+		session.Attempts++
+		if session.Attempts >= config.MaxAttempts {
+			redis.DeleteSession(sessionID)
+			logEventAndRespond(c, logger, "Maximum verification attempts exceeded", models.EventTypeVerifyLink, "failure", session.Email, session.Attempts, session.Resends, http.StatusTooManyRequests)
+			return
+		}
+
+		logEventAndRespond(c, logger, "Invalid magic link", models.EventTypeVerifyLink, "failure", session.Email, session.Attempts, session.Resends, http.StatusUnauthorized)
+		return
+	}
+
+	// The token is single-use: consuming the session invalidates the link
+	redis.DeleteSession(sessionID)
+	logEventAndRespond(c, logger, "Magic link verified successfully", models.EventTypeVerifyLink, "success", session.Email, session.Attempts, session.Resends, http.StatusOK)
+}
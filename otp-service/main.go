@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 	"otp-service/config"
+	"otp-service/delivery"
 	"otp-service/handlers"
 	"otp-service/middleware"
 	"otp-service/redis"
@@ -32,6 +33,8 @@ func main() {
 		log.Fatal("Rate limiter init failed:", err)
 	}
 
+	delivery.InitChannels()
+
 	utils.StartCleanupJob()
 
 	r := gin.Default()
@@ -39,6 +42,23 @@ func main() {
 	// OTP endpoints
 	r.POST("/otp/generate", middleware.RateLimitMiddleware(), handlers.GenerateOTPHandler)
 	r.POST("/otp/verify", middleware.RateLimitMiddleware(), handlers.VerifyOTPHandler)
+	r.POST("/otp/verify-link", middleware.RateLimitMiddleware(), handlers.VerifyMagicLinkHandler)
+
+	// TOTP (authenticator app) endpoints
+	r.POST("/otp/totp/enroll", middleware.RateLimitMiddleware(), handlers.TOTPEnrollHandler)
+	r.POST("/otp/totp/confirm", middleware.RateLimitMiddleware(), handlers.TOTPConfirmHandler)
+	r.POST("/otp/totp/verify", middleware.RateLimitMiddleware(), handlers.TOTPVerifyHandler)
+	r.GET("/otp/totp/status", handlers.TOTPStatusHandler)
+
+	// Recovery code endpoints
+	r.POST("/otp/recovery-codes", middleware.RateLimitMiddleware(), handlers.GenerateRecoveryCodesHandler)
+	r.POST("/otp/recovery/verify", middleware.RateLimitMiddleware(), handlers.VerifyRecoveryCodeHandler)
+
+	// Delivery preference endpoints
+	r.POST("/otp/delivery-preference", middleware.RateLimitMiddleware(), handlers.SetDeliveryPreferenceHandler)
+	r.POST("/otp/delivery-preference/verify", middleware.RateLimitMiddleware(), handlers.VerifyDeliveryPreferenceHandler)
+	r.GET("/otp/delivery-preference", middleware.RateLimitMiddleware(), handlers.GetDeliveryPreferenceHandler)
+	r.GET("/otp/delivery-status", middleware.RateLimitMiddleware(), handlers.DeliveryStatusHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
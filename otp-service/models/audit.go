@@ -11,7 +11,6 @@ type OTPEvent struct {
 	Email       string    `json:"email" gorm:"type:varchar(255);not null;index"`
 	EventType   string    `json:"event_type" gorm:"type:varchar(50);not null;index"`
 	EventStatus string    `json:"event_status" gorm:"type:varchar(50);not null"`
-	OTPHash     string    `json:"otp_hash" gorm:"type:varchar(255)"`
 	IPAddress   string    `json:"ip_address" gorm:"type:varchar(45)"`
 	UserAgent   string    `json:"user_agent" gorm:"type:text"`
 	Attempts    int       `json:"attempts" gorm:"default:0"`
@@ -32,6 +31,14 @@ const (
 	EventTypeResend   = "RESEND"
 	EventTypeExpire   = "EXPIRE"
 	EventTypeRateLimit = "RATE_LIMIT"
+	EventTypeVerifyLink = "VERIFY_LINK"
+	EventTypeTOTPEnroll  = "TOTP_ENROLL"
+	EventTypeTOTPConfirm = "TOTP_CONFIRM"
+	EventTypeTOTPVerify  = "TOTP_VERIFY"
+	EventTypeRecoveryGenerate = "RECOVERY_GENERATE"
+	EventTypeRecoveryUse      = "RECOVERY_USE"
+	EventTypeDeliveryPreference = "DELIVERY_PREFERENCE"
+	EventTypeDeliveryPreferenceVerify = "DELIVERY_PREFERENCE_VERIFY"
 )
 
 // EventStatus constants
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// DeliveryPreference stores the channel a user wants OTPs delivered on.
+// Phone is only ever set once the user has proven they own it.
+type DeliveryPreference struct {
+	ID              uint       `json:"-" gorm:"primaryKey;autoIncrement"`
+	Email           string     `json:"email" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Channel         string     `json:"channel" gorm:"type:varchar(20);not null"`
+	Phone           string     `json:"phone,omitempty" gorm:"type:varchar(20)"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (DeliveryPreference) TableName() string {
+	return "delivery_preferences"
+}
+
+// VerifiedPhone returns the phone number SMS and voice may be sent to, or ""
+func (p *DeliveryPreference) VerifiedPhone() string {
+	if p == nil || p.PhoneVerifiedAt == nil {
+		return ""
+	}
+	return p.Phone
+}
+
+// Allows reports whether an OTP may be sent over channel for this user: email
+// always, SMS and voice once a phone is verified, webhook when it was chosen
+func (p *DeliveryPreference) Allows(channel string) bool {
+	switch channel {
+	case "email":
+		return true
+	case "sms", "voice":
+		return p.VerifiedPhone() != ""
+	default:
+		return p != nil && p.Channel == channel
+	}
+}
+
+type DeliveryPreferenceRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Channel string `json:"channel" binding:"required,oneof=email sms voice webhook"`
+	Phone   string `json:"phone" binding:"omitempty,e164"`
+}
+
+type DeliveryPreferenceVerifyRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// PhoneVerification is a pending SMS or voice preference, kept in Redis until
+// the code sent to Phone is confirmed
+type PhoneVerification struct {
+	Email    string `json:"email"`
+	Channel  string `json:"channel"`
+	Phone    string `json:"phone"`
+	CodeHash string `json:"code_hash"`
+	KeyID    string `json:"key_id"`
+	Attempts int    `json:"attempts"`
+}
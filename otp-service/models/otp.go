@@ -2,15 +2,34 @@ package models
 
 import "time"
 
+// Delivery modes a client can pick at signup
+const (
+	ModeCode = "code"
+	ModeLink = "link"
+	ModeTOTP = "totp"
+)
+
 type OTPSession struct {
 	SessionID string    `json:"session_id"`
-	OTPHash   string    `json:"otp_hash"`
+	OTPHash   string    `json:"otp_hash"` // HMAC-SHA256 of the OTP, hex
+	KeyID     string    `json:"key_id"`   // pepper that produced OTPHash
 	CreatedAt time.Time `json:"created_at"`
 	Attempts  int       `json:"attempts"`
 	Resends   int       `json:"resends"`
 	Email string `json:"email"`
+	Mode      string    `json:"mode"`
+	// DeliveryChannel and DeliveryRef identify the last OTP or link sent, so
+	// its delivery status can be looked up
+	DeliveryChannel string `json:"delivery_channel,omitempty"`
+	DeliveryRef     string `json:"delivery_ref,omitempty"`
 }
 
 type OTPRequest struct {
 	Email string `json:"email" binding:"required,email"`
+	Mode  string `json:"mode" binding:"omitempty,oneof=code link totp"`
+	// Channel overrides the user's delivery preference for this request, among
+	// the channels the preference allows. SMS and voice go to its verified phone.
+	Channel string `json:"channel" binding:"omitempty,oneof=email sms voice webhook"`
+	// Locale selects the email language, e.g. "fr-CA"; empty uses the default
+	Locale string `json:"locale" binding:"omitempty,max=35"`
 }
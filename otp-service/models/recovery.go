@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RecoveryCode is a single-use backup code; only its bcrypt hash is stored.
+// LookupHash is a peppered HMAC of the code used to find the row, so redeeming
+// a code costs one bcrypt comparison instead of one per unused code.
+type RecoveryCode struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Email      string     `json:"email" gorm:"type:varchar(255);not null;index"`
+	LookupHash string     `json:"-" gorm:"type:varchar(64);not null;default:'';index"`
+	CodeHash   string     `json:"-" gorm:"type:varchar(255);not null"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
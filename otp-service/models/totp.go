@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TOTPEnrollment stores a user's authenticator app secret, encrypted at rest
+type TOTPEnrollment struct {
+	ID               uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Email            string     `json:"email" gorm:"type:varchar(255);not null;uniqueIndex"`
+	SecretCiphertext string     `json:"-" gorm:"type:text;not null"`
+	ConfirmedAt      *time.Time `json:"confirmed_at"`
+	LastUsedStep     int64      `json:"-" gorm:"not null;default:0"` // last accepted time step, blocks code replay
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (TOTPEnrollment) TableName() string {
+	return "totp_enrollments"
+}
+
+// Confirmed reports whether the user has proven possession of the secret
+func (e TOTPEnrollment) Confirmed() bool {
+	return e.ConfirmedAt != nil
+}
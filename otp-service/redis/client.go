@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"otp-service/config"
 	"otp-service/models"
+	"strings"
 	"time"
 	"github.com/redis/go-redis/v9"
 )
@@ -80,7 +81,78 @@ func IncrementReattempts(sessionID string) error {
 	// Save updated session with the same TTL
 	return StoreSession(session, ttl)
 }
+// totpAttemptsKey counts a user's TOTP attempts across every session and endpoint
+func totpAttemptsKey(email string) string {
+	return "totp_attempts:" + strings.ToLower(email)
+}
+
+// ReserveTOTPAttempt counts a TOTP attempt for email before the code is checked,
+// so concurrent guesses share one budget. The count lasts window from the first
+// attempt. The attempt after maxFailures locks the user out for lockout, and while
+// locked it returns the time left. A correct code should call ResetTOTPAttempts.
+func ReserveTOTPAttempt(email string, maxFailures int64, window, lockout time.Duration) (time.Duration, error) {
+	ctx := context.Background()
+	key := totpAttemptsKey(email)
+
+	count, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case count == 1:
+		return 0, rdb.Expire(ctx, key, window).Err()
+	case count <= maxFailures:
+		return 0, nil
+	case count == maxFailures+1:
+		return lockout, rdb.Expire(ctx, key, lockout).Err()
+	}
+	return rdb.TTL(ctx, key).Result()
+}
+
+// ResetTOTPAttempts clears the attempt count after a correct TOTP code
+func ResetTOTPAttempts(email string) {
+	rdb.Del(context.Background(), totpAttemptsKey(email))
+}
+
+// phoneVerificationKey holds the pending phone verification of a user
+func phoneVerificationKey(email string) string {
+	return "phone_verification:" + strings.ToLower(email)
+}
+
+// StorePhoneVerification replaces any pending phone verification for v.Email
+func StorePhoneVerification(v models.PhoneVerification, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(context.Background(), phoneVerificationKey(v.Email), data, ttl).Err()
+}
+
+func GetPhoneVerification(email string) (models.PhoneVerification, error) {
+	val, err := rdb.Get(context.Background(), phoneVerificationKey(email)).Result()
+	if err != nil {
+		return models.PhoneVerification{}, err
+	}
+	var v models.PhoneVerification
+	if err := json.Unmarshal([]byte(val), &v); err != nil {
+		return models.PhoneVerification{}, err
+	}
+	return v, nil
+}
 
+// IncrementPhoneVerificationAttempts records a wrong code, preserving TTL
+func IncrementPhoneVerificationAttempts(v models.PhoneVerification) error {
+	v.Attempts++
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(context.Background(), phoneVerificationKey(v.Email), data, redis.KeepTTL).Err()
+}
+
+func DeletePhoneVerification(email string) {
+	rdb.Del(context.Background(), phoneVerificationKey(email))
+}
 
 func CloseRedis() error {
 	if rdb != nil {
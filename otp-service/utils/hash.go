@@ -1,14 +1,68 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"otp-service/config"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashOTP(otp string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(otp), bcrypt.DefaultCost)
+// HashOTP returns the hex HMAC-SHA256 of otp under the active pepper, and that pepper's key ID.
+// OTPs are short-lived and the pepper never leaves the server, so a fast keyed hash is
+// enough; without the pepper a leaked hash cannot be brute-forced offline.
+func HashOTP(otp string) (string, string, error) {
+	keyID := config.AppConfig.OTPPepperKeyID
+	pepper, ok := config.AppConfig.OTPPeppers[keyID]
+	if !ok {
+		return "", "", errors.New("no active OTP pepper configured")
+	}
+	return hmacHex(pepper, otp), keyID, nil
+}
+
+// CompareOTP checks otp against a hash produced by HashOTP with the pepper keyID, in constant time
+func CompareOTP(hash, keyID, otp string) bool {
+	pepper, ok := config.AppConfig.OTPPeppers[keyID]
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(hash), []byte(hmacHex(pepper, otp)))
+}
+
+func hmacHex(key []byte, msg string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashRecoveryCode hashes a normalized recovery code with bcrypt
+func HashRecoveryCode(code string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
 	return string(bytes), err
 }
 
-func CompareOTP(hash, otp string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(otp)) == nil
+// RecoveryLookupHash returns the HMAC of a normalized recovery code under the active pepper.
+// It only narrows the lookup to a single row; the bcrypt hash still has to match.
+func RecoveryLookupHash(code string) (string, error) {
+	pepper, ok := config.AppConfig.OTPPeppers[config.AppConfig.OTPPepperKeyID]
+	if !ok {
+		return "", errors.New("no active OTP pepper configured")
+	}
+	return hmacHex(pepper, code), nil
+}
+
+// RecoveryLookupHashes returns the HMAC of code under every configured pepper,
+// so codes issued before a key rotation can still be found
+func RecoveryLookupHashes(code string) []string {
+	hashes := make([]string, 0, len(config.AppConfig.OTPPeppers))
+	for _, pepper := range config.AppConfig.OTPPeppers {
+		hashes = append(hashes, hmacHex(pepper, code))
+	}
+	return hashes
+}
+
+func CompareRecoveryCode(hash, code string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil
 }
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"otp-service/config"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePeppers installs peppers for the duration of a test
+func usePeppers(t testing.TB, active string, peppers map[string][]byte) {
+	t.Helper()
+	saved, savedID := config.AppConfig.OTPPeppers, config.AppConfig.OTPPepperKeyID
+	config.AppConfig.OTPPeppers = peppers
+	config.AppConfig.OTPPepperKeyID = active
+	t.Cleanup(func() {
+		config.AppConfig.OTPPeppers = saved
+		config.AppConfig.OTPPepperKeyID = savedID
+	})
+}
+
+func pepper(b byte) []byte {
+	return bytes.Repeat([]byte{b}, config.MinPepperBytes)
+}
+
+func TestHashOTPKeyRotation(t *testing.T) {
+	usePeppers(t, "2024-01", map[string][]byte{"2024-01": pepper(1)})
+
+	oldHash, oldKeyID, err := HashOTP("123456")
+	if err != nil {
+		t.Fatalf("HashOTP: %v", err)
+	}
+	if oldKeyID != "2024-01" {
+		t.Fatalf("key ID = %q, want 2024-01", oldKeyID)
+	}
+
+	// Rotate: add a new key and make it active, keeping the old one for live OTPs
+	usePeppers(t, "2024-02", map[string][]byte{"2024-01": pepper(1), "2024-02": pepper(2)})
+
+	newHash, newKeyID, err := HashOTP("123456")
+	if err != nil {
+		t.Fatalf("HashOTP after rotation: %v", err)
+	}
+	if newKeyID != "2024-02" {
+		t.Errorf("key ID after rotation = %q, want 2024-02", newKeyID)
+	}
+	if newHash == oldHash {
+		t.Error("hash did not change with the pepper")
+	}
+	if !CompareOTP(oldHash, oldKeyID, "123456") {
+		t.Error("OTP hashed before rotation no longer verifies")
+	}
+	if !CompareOTP(newHash, newKeyID, "123456") {
+		t.Error("OTP hashed after rotation does not verify")
+	}
+	if CompareOTP(oldHash, newKeyID, "123456") {
+		t.Error("old hash verified under the new key ID")
+	}
+
+	// Retire the old key: its hashes must stop verifying
+	usePeppers(t, "2024-02", map[string][]byte{"2024-02": pepper(2)})
+	if CompareOTP(oldHash, oldKeyID, "123456") {
+		t.Error("OTP verified under a retired key")
+	}
+}
+
+func TestHashOTPWithoutActivePepper(t *testing.T) {
+	usePeppers(t, "missing", map[string][]byte{"other": pepper(1)})
+
+	if _, _, err := HashOTP("123456"); err == nil {
+		t.Fatal("HashOTP succeeded without an active pepper")
+	}
+}
+
+func TestCompareOTPRejectsNearMisses(t *testing.T) {
+	usePeppers(t, "k", map[string][]byte{"k": pepper(7)})
+
+	hash, keyID, err := HashOTP("123456")
+	if err != nil {
+		t.Fatalf("HashOTP: %v", err)
+	}
+
+	// The comparison goes through hmac.Equal, so a hash differing in its first or
+	// last byte, or in length, must fail just like a completely different one
+	flip := func(s string, i int) string {
+		b := []byte(s)
+		if b[i] == '0' {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+		return string(b)
+	}
+	tests := []struct {
+		name  string
+		hash  string
+		keyID string
+		otp   string
+		want  bool
+	}{
+		{"match", hash, keyID, "123456", true},
+		{"wrong otp", hash, keyID, "123457", false},
+		{"first byte differs", flip(hash, 0), keyID, "123456", false},
+		{"last byte differs", flip(hash, len(hash)-1), keyID, "123456", false},
+		{"truncated", hash[:len(hash)-2], keyID, "123456", false},
+		{"extended", hash + "00", keyID, "123456", false},
+		{"uppercase hex", strings.ToUpper(hash), keyID, "123456", false},
+		{"empty hash", "", keyID, "123456", false},
+		{"unknown key ID", hash, "nope", "123456", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareOTP(tt.hash, tt.keyID, tt.otp); got != tt.want {
+				t.Errorf("CompareOTP = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoveryLookupHashesCoverEveryPepper(t *testing.T) {
+	usePeppers(t, "old", map[string][]byte{"old": pepper(1)})
+	stored, err := RecoveryLookupHash("k3mz9p2wqa")
+	if err != nil {
+		t.Fatalf("RecoveryLookupHash: %v", err)
+	}
+
+	usePeppers(t, "new", map[string][]byte{"old": pepper(1), "new": pepper(2)})
+	found := false
+	for _, h := range RecoveryLookupHashes("k3mz9p2wqa") {
+		found = found || h == stored
+	}
+	if !found {
+		t.Error("code stored before rotation is not found after it")
+	}
+}
+
+func BenchmarkHashOTPHMAC(b *testing.B) {
+	usePeppers(b, "bench", map[string][]byte{"bench": pepper(3)})
+	otp := GenerateSecureOTP(config.OTPLength)
+
+	b.Run("hash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			HashOTP(otp)
+		}
+	})
+
+	hash, keyID, err := HashOTP(otp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("compare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			CompareOTP(hash, keyID, otp)
+		}
+	})
+}
+
+// BenchmarkHashOTPBcrypt measures the bcrypt hash OTPs used before HashOTP, for comparison
+func BenchmarkHashOTPBcrypt(b *testing.B) {
+	otp := []byte(GenerateSecureOTP(config.OTPLength))
+
+	b.Run("hash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bcrypt.GenerateFromPassword(otp, bcrypt.DefaultCost)
+		}
+	})
+
+	hash, err := bcrypt.GenerateFromPassword(otp, bcrypt.DefaultCost)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("compare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bcrypt.CompareHashAndPassword(hash, otp)
+		}
+	})
+}
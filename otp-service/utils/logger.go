@@ -39,7 +39,6 @@ type OTPEventParams struct {
 	SessionID   string
 	EventType   string
 	EventStatus string
-	OTPHash     string
 	Msg         string
 	Attempts    int
 	Resends     int
@@ -77,7 +76,6 @@ func (l *Logger) LogOTPEvent(c *gin.Context, p OTPEventParams) {
 		SessionID:   p.SessionID,
 		EventType:   p.EventType,
 		EventStatus: p.EventStatus,
-		OTPHash:     p.OTPHash,
 		Msg:         p.Msg,
 		Attempts:    p.Attempts,
 		Resends:     p.Resends,
@@ -93,9 +91,6 @@ func (l *Logger) LogOTPEvent(c *gin.Context, p OTPEventParams) {
 		if event.Msg != "" {
 			logMsg += fmt.Sprintf(", Error: %s", event.Msg)
 		}
-		if event.OTPHash != "" {
-			logMsg += ", OTPHash: [REDACTED IN PRODUCTION]"
-		}
 		log.Println(logMsg)
 	} else {
 		log.Printf(
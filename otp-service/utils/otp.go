@@ -2,6 +2,8 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/hex"
+	"errors"
 )
 
 func GenerateSecureOTP(length int) string {
@@ -12,3 +14,12 @@ func GenerateSecureOTP(length int) string {
 	}
 	return string(bytes)
 }
+
+// GenerateMagicLinkToken returns a hex-encoded random token of n bytes for magic-link logins
+func GenerateMagicLinkToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errors.New("failed to generate magic link token")
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// recoveryAlphabet omits look-alike characters (0/o, 1/l/i)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCode returns a random code of length characters split into two groups, e.g. "k3mz9-p2wqa"
+func GenerateRecoveryCode(length int) (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < length; i++ {
+		if i == length/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.New("failed to generate recovery code")
+		}
+		b.WriteByte(recoveryAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeRecoveryCode strips separators and whitespace and lowercases user input
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
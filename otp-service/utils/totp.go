@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"otp-service/config"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random base32-encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	bytes := make([]byte, config.TOTPSecretBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errors.New("failed to generate TOTP secret")
+	}
+	return totpEncoding.EncodeToString(bytes), nil
+}
+
+// TOTPStep returns the RFC 6238 time step for t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(config.TOTPPeriod/time.Second)
+}
+
+// TOTPCode computes the RFC 6238 (HMAC-SHA1) code for the given secret and time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < config.TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", config.TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP checks code against the steps around now within the configured skew window.
+// Steps at or before lastUsedStep are skipped so a code can never be accepted twice.
+// It returns the matched step.
+func ValidateTOTP(secret, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	if len(code) != config.TOTPDigits {
+		return 0, false
+	}
+	current := TOTPStep(now)
+	for step := current - config.TOTPSkewSteps; step <= current+config.TOTPSkewSteps; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI understood by authenticator apps
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", config.TOTPDigits))
+	params.Set("period", fmt.Sprintf("%d", int(config.TOTPPeriod/time.Second)))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPQRCodePNG renders the provisioning URI as a PNG QR code
+func TOTPQRCodePNG(uri string) ([]byte, error) {
+	return qrcode.Encode(uri, qrcode.Medium, config.TOTPQRCodeSize)
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"resource-service/models"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var DB *gorm.DB
+
+// InitDatabase connects to the existing resources database and migrates its tables
+func InitDatabase() error {
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		AppConfig.DBHost, AppConfig.DBPort, AppConfig.DBUser, AppConfig.DBPassword, AppConfig.DBName, AppConfig.DBSSLMode,
+	)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	// Connection pool settings
+	sqlDB.SetMaxOpenConns(25)
+	sqlDB.SetMaxIdleConns(5)
+	sqlDB.SetConnMaxLifetime(5 * time.Minute)
+
+	DB = db
+	log.Println("Connected to PostgreSQL successfully")
+
+	if err := DB.AutoMigrate(&models.Resource{}, &models.ResourceShare{}, &models.Group{}, &models.GroupMember{}, &models.ACLEvent{}, &models.ResourceVersion{}); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := ensureSearchVector(DB); err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+	return nil
+}
+
+// ensureSearchVector adds the generated tsvector column over name and description
+// that backs full-text search, and its GIN index. Postgres keeps it up to date, so
+// the Resource model does not map it.
+func ensureSearchVector(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE resources ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('english', coalesce(name, '') || ' ' || coalesce(description, ''))) STORED`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_resources_search_vector ON resources USING GIN (search_vector)`).Error
+}
+
+// CloseDatabaseConnection closes the database connection if one was opened
+func CloseDatabaseConnection() {
+	if DB == nil {
+		return
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Printf("Failed to retrieve sql.DB for closing: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("Error while closing DB connection: %v", err)
+	} else {
+		log.Println("Database connection closed successfully")
+	}
+}
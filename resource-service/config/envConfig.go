@@ -6,10 +6,27 @@ import (
 	"strconv"
 )
 
+// Resource stores selectable with RESOURCE_STORE
+const (
+	StorePostgres = "postgres"
+	StoreMemory   = "memory"
+)
+
 type Config struct {
 	// App
 	AppEnv              string
 	ResourceServicePort int
+
+	// Storage: "postgres", or "memory" for tests and local runs without a database
+	ResourceStore string
+
+	// Database
+	DBHost     string
+	DBPort     int
+	DBUser     string
+	DBPassword string
+	DBName     string
+	DBSSLMode  string
 }
 
 var AppConfig Config
@@ -18,7 +35,13 @@ func InitConfig() {
 	var err error
 
 	AppConfig = Config{
-		AppEnv: getEnv("APP_ENV", "development"),
+		AppEnv:        getEnv("APP_ENV", "development"),
+		ResourceStore: getEnv("RESOURCE_STORE", StorePostgres),
+		DBHost:        getEnv("DB_HOST", "localhost"),
+		DBUser:        getEnv("DB_USER", "postgres"),
+		DBPassword:    getEnv("DB_PASSWORD", ""),
+		DBName:        getEnv("DB_NAME", "resources"),
+		DBSSLMode:     getEnv("DB_SSLMODE", "disable"),
 	}
 
 	// Parse Resource Service Port
@@ -26,6 +49,16 @@ func InitConfig() {
 	if err != nil {
 		log.Fatalf("Invalid RESOURCE_SERVICE_PORT: %v", err)
 	}
+
+	// Parse DB_PORT
+	AppConfig.DBPort, err = parseEnvInt("DB_PORT", 5432)
+	if err != nil {
+		log.Fatalf("Invalid DB_PORT: %v", err)
+	}
+
+	if AppConfig.ResourceStore != StorePostgres && AppConfig.ResourceStore != StoreMemory {
+		log.Fatalf("Invalid RESOURCE_STORE %q: must be %s or %s", AppConfig.ResourceStore, StorePostgres, StoreMemory)
+	}
 }
 
 func getEnv(key, defaultVal string) string {
@@ -41,4 +74,3 @@ func parseEnvInt(key string, defaultVal int) (int, error) {
 	}
 	return defaultVal, nil
 }
- 
\ No newline at end of file
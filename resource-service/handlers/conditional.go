@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"resource-service/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns the strong entity tag of a resource's current version
+func etag(resource *models.Resource) string {
+	return `"` + strconv.FormatInt(resource.Version, 10) + `"`
+}
+
+// etagListMatches reports whether an If-Match or If-None-Match value, a
+// comma-separated list of entity tags or "*", names tag. Weak comparison, for
+// If-None-Match, ignores a W/ prefix; strong comparison, for If-Match, never
+// matches a weak tag.
+func etagListMatches(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers 304 when the request's If-None-Match names the resource's
+// current version
+func notModified(c *gin.Context, resource *models.Resource) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !etagListMatches(header, etag(resource), true) {
+		return false
+	}
+	c.Header("ETag", etag(resource))
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// requireIfMatch makes an update or delete conditional on the caller having seen
+// the resource's current version. It answers 428 when If-Match is missing and 412,
+// with the current ETag, when it names another version.
+func requireIfMatch(c *gin.Context, resource *models.Resource) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the resource's ETag is required"})
+		return false
+	}
+	if !etagListMatches(header, etag(resource), false) {
+		c.Header("ETag", etag(resource))
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource has changed since it was read", "version": resource.Version})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"resource-service/middleware"
+	"resource-service/models"
+	"resource-service/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// loadGroup loads the group named by the :id parameter. Members and admins can
+// see a group, and only its owner and admins may manage it when manage is set.
+// Like resources, a group the caller cannot see answers 404.
+func loadGroup(c *gin.Context, manage bool) (*models.Group, bool) {
+	user := middleware.CurrentUser(c)
+	group, err := groups.Group(c.Request.Context(), c.Param("id"))
+	if err == nil && !user.IsAdmin() && !containsString(group.Members, user.ID) {
+		err = repository.ErrGroupNotFound
+	}
+	if errors.Is(err, repository.ErrGroupNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return nil, false
+	}
+	if err != nil {
+		respondGroupError(c, err)
+		return nil, false
+	}
+	if manage && !user.IsAdmin() && group.OwnerID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can manage its members"})
+		return nil, false
+	}
+	return group, true
+}
+
+// respondGroupError answers 500 for group store errors
+func respondGroupError(c *gin.Context, err error) {
+	log.Printf("[ERROR] Group store error: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to access groups"})
+}
+
+// CreateGroup creates a group owned by the caller, with the caller as its first member
+func CreateGroup(c *gin.Context) {
+	var req models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	group := models.Group{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		OwnerID:   user.ID,
+		Members:   []string{user.ID},
+		CreatedAt: time.Now(),
+	}
+	if err := groups.CreateGroup(c.Request.Context(), &group); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"group": group})
+}
+
+// ListGroups returns the groups the caller is a member of
+func ListGroups(c *gin.Context) {
+	groupList, err := groups.ListGroups(c.Request.Context(), middleware.CurrentUser(c).ID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groupList})
+}
+
+// GetGroup returns a group and its members
+func GetGroup(c *gin.Context) {
+	group, ok := loadGroup(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
+// AddGroupMember puts a user in a group; only the group owner and admins may
+func AddGroupMember(c *gin.Context) {
+	group, ok := loadGroup(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := groups.AddMember(c.Request.Context(), group.ID, req.UserID, middleware.CurrentUser(c).ID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member added successfully"})
+}
+
+// RemoveGroupMember takes a user out of a group; only the group owner and
+// admins may, and the owner cannot be removed
+func RemoveGroupMember(c *gin.Context) {
+	group, ok := loadGroup(c, true)
+	if !ok {
+		return
+	}
+
+	userID := c.Param("user_id")
+	if userID == group.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The group owner cannot be removed"})
+		return
+	}
+
+	err := groups.RemoveMember(c.Request.Context(), group.ID, userID, middleware.CurrentUser(c).ID)
+	if errors.Is(err, repository.ErrMemberNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// GetGroupEvents returns the membership history of a group; only the group
+// owner and admins may see it
+func GetGroupEvents(c *gin.Context) {
+	group, ok := loadGroup(c, true)
+	if !ok {
+		return
+	}
+
+	events, err := groups.GroupEvents(c.Request.Context(), group.ID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_id": group.ID, "events": events})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
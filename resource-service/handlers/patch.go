@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"resource-service/middleware"
+	"resource-service/models"
+	"resource-service/patch"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Patch formats accepted by PATCH /resources/:id. Plain application/json is
+// refused with 415: it does not say which of the two a body is.
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// maxPatchBytes bounds the size of a patch document
+const maxPatchBytes = 64 << 10
+
+// patchDocument is the JSON document patches apply to: the editable fields of a resource
+func patchDocument(resource *models.Resource) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        resource.Name,
+		"description": resource.Description,
+	}
+}
+
+// applyPatchDocument validates a patched document and copies it onto resource.
+// A missing description is cleared; the name is required.
+func applyPatchDocument(resource *models.Resource, doc interface{}) error {
+	fields, ok := doc.(map[string]interface{})
+	if !ok {
+		return errors.New("the patched resource must be a JSON object")
+	}
+
+	var unknown []string
+	for key := range fields {
+		if key != "name" && key != "description" {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("only name and description can be patched, not %s", strings.Join(unknown, ", "))
+	}
+
+	name, ok := fields["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return errors.New("name is required and must be a non-empty string")
+	}
+	description := ""
+	if raw, present := fields["description"]; present {
+		if description, ok = raw.(string); !ok {
+			return errors.New("description must be a string")
+		}
+	}
+
+	resource.Name = name
+	resource.Description = description
+	return nil
+}
+
+// respondPatchError answers 400 for a malformed patch, 409 for a failed test
+// operation and 422 for a patch that cannot be applied to the resource
+func respondPatchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, patch.ErrInvalidPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, patch.ErrTestFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	}
+}
+
+// PatchResource applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// to a resource's name and description. Like PUT it needs the editor role and
+// If-Match; unlike PUT it can clear the description.
+func PatchResource(c *gin.Context) {
+	existingResource, _, ok := authorize(c, models.RoleEditor)
+	if !ok || !requireIfMatch(c, existingResource) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var patched interface{}
+	switch c.ContentType() {
+	case contentTypeMergePatch:
+		patched, err = patch.MergePatch(patchDocument(existingResource), body)
+	case contentTypeJSONPatch:
+		patched, err = patch.JSONPatch(patchDocument(existingResource), body)
+	default:
+		c.Header("Accept-Patch", contentTypeMergePatch+", "+contentTypeJSONPatch)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + contentTypeMergePatch + " or " + contentTypeJSONPatch})
+		return
+	}
+	if err != nil {
+		respondPatchError(c, err)
+		return
+	}
+	if err := applyPatchDocument(existingResource, patched); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	existingResource.UpdatedAt = time.Now()
+
+	if err := repo.Update(c.Request.Context(), existingResource, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(existingResource))
+	c.JSON(http.StatusOK, gin.H{
+		"resource":    existingResource,
+		"user_id":     c.GetHeader("X-User-ID"),
+		"user_email":  c.GetHeader("X-User-Email"),
+		"user_scopes": c.GetHeader("X-User-Scopes"),
+	})
+}
@@ -1,123 +1,216 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"resource-service/middleware"
 	"resource-service/models"
+	"resource-service/repository"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// In-memory storage for resources
-var resources = make(map[string]models.Resource)
-
-// populateDummyData creates and adds dummy resources to the map
-func populateDummyData() {
-	// Create dummy resources
-	dummyResources := []models.Resource{
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440001",
-			Name:        "User Management System",
-			Description: "A comprehensive system for managing user accounts, roles, and permissions",
-			CreatedAt:   time.Now().Add(-24 * time.Hour),
-			UpdatedAt:   time.Now().Add(-2 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440002",
-			Name:        "Payment Gateway",
-			Description: "Secure payment processing system with multiple payment method support",
-			CreatedAt:   time.Now().Add(-48 * time.Hour),
-			UpdatedAt:   time.Now().Add(-6 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440003",
-			Name:        "Analytics Dashboard",
-			Description: "Real-time analytics and reporting dashboard with interactive charts",
-			CreatedAt:   time.Now().Add(-72 * time.Hour),
-			UpdatedAt:   time.Now().Add(-12 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440004",
-			Name:        "Email Service",
-			Description: "Reliable email delivery service with template management and tracking",
-			CreatedAt:   time.Now().Add(-96 * time.Hour),
-			UpdatedAt:   time.Now().Add(-1 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440005",
-			Name:        "File Storage System",
-			Description: "Cloud-based file storage with version control and sharing capabilities",
-			CreatedAt:   time.Now().Add(-120 * time.Hour),
-			UpdatedAt:   time.Now().Add(-4 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440006",
-			Name:        "Notification Service",
-			Description: "Multi-channel notification system supporting push, SMS, and email",
-			CreatedAt:   time.Now().Add(-144 * time.Hour),
-			UpdatedAt:   time.Now().Add(-8 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440007",
-			Name:        "API Gateway",
-			Description: "Centralized API management with rate limiting and authentication",
-			CreatedAt:   time.Now().Add(-168 * time.Hour),
-			UpdatedAt:   time.Now().Add(-3 * time.Hour),
-		},
-		{
-			ID:          "550e8400-e29b-41d4-a716-446655440008",
-			Name:        "Database Management",
-			Description: "Database administration tools with backup and recovery features",
-			CreatedAt:   time.Now().Add(-192 * time.Hour),
-			UpdatedAt:   time.Now().Add(-5 * time.Hour),
-		},
-	}
-
-	// Add dummy resources to the map
-	for _, resource := range dummyResources {
-		resources[resource.ID] = resource
+// repo stores resources and groups stores the groups they can be shared with;
+// both are set once at startup with UseRepository
+var (
+	repo   repository.ResourceRepository
+	groups repository.GroupRepository
+)
+
+// UseRepository sets the stores the resource, sharing and group handlers read and write
+func UseRepository(resources repository.ResourceRepository, groupStore repository.GroupRepository) {
+	repo = resources
+	groups = groupStore
+}
+
+// currentViewer describes the caller to the repository: their user ID, the
+// groups they belong to and whether they are an admin
+func currentViewer(c *gin.Context) (repository.Viewer, error) {
+	user := middleware.CurrentUser(c)
+	groupIDs, err := groups.GroupsOf(c.Request.Context(), user.ID)
+	if err != nil {
+		return repository.Viewer{}, err
 	}
+	return repository.Viewer{UserID: user.ID, Groups: groupIDs, Admin: user.IsAdmin()}, nil
 }
 
-// init function to populate dummy data when the package is imported
-func init() {
-	populateDummyData()
+// authorize loads the resource named by the :id parameter and checks that the
+// caller holds at least need on it. It answers 404 when the caller has no role
+// on the resource, so its existence is not revealed, and 403 when they can see
+// it but their role is too weak.
+func authorize(c *gin.Context, need models.Role) (*models.Resource, models.Role, bool) {
+	return authorizeFrom(c, need, repo.Get)
 }
 
-// GetAllResources returns all resources
+// authorizeDeleted is authorize for resources in the trash
+func authorizeDeleted(c *gin.Context, need models.Role) (*models.Resource, models.Role, bool) {
+	return authorizeFrom(c, need, repo.GetDeleted)
+}
+
+type getFunc func(ctx context.Context, viewer repository.Viewer, id string) (*models.Resource, models.Role, error)
+
+func authorizeFrom(c *gin.Context, need models.Role, get getFunc) (*models.Resource, models.Role, bool) {
+	viewer, err := currentViewer(c)
+	if err != nil {
+		respondRepoError(c, err)
+		return nil, "", false
+	}
+	resource, role, err := get(c.Request.Context(), viewer, c.Param("id"))
+	if err != nil {
+		respondRepoError(c, err)
+		return nil, "", false
+	}
+	if !role.Includes(need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This action requires the " + string(need) + " role", "role": role})
+		return nil, "", false
+	}
+	return resource, role, true
+}
+
+// respondRepoError answers 404 for unknown resources, 412 for version conflicts
+// and 500 for anything else
+func respondRepoError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Resource has changed since it was read"})
+		return
+	}
+	log.Printf("[ERROR] Resource store error: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to access resources"})
+}
+
+// Page sizes for GET /resources
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parseListOptions reads the filter, sort and paging query parameters of GET /resources
+func parseListOptions(c *gin.Context) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		SharedWithMe: c.Query("shared_with_me") == "true",
+		Name:         c.Query("name"),
+		OwnerID:      c.Query("owner_id"),
+		Search:       c.Query("q"),
+		Sort:         repository.SortCreatedAt,
+		Limit:        defaultPageSize,
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = min(limit, maxPageSize)
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		opts.Desc = strings.HasPrefix(raw, "-")
+		opts.Sort = repository.SortField(strings.TrimPrefix(raw, "-"))
+		if !slices.Contains(repository.SortFields, opts.Sort) {
+			return opts, fmt.Errorf("sort must be one of created_at, updated_at, optionally prefixed with -")
+		}
+	}
+
+	for param, dest := range map[string]**time.Time{
+		"created_after":  &opts.CreatedAfter,
+		"created_before": &opts.CreatedBefore,
+		"updated_after":  &opts.UpdatedAfter,
+		"updated_before": &opts.UpdatedBefore,
+	} {
+		if raw := c.Query(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			*dest = &t
+		}
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := repository.DecodeCursor(raw, opts.Sort, opts.Desc)
+		if err != nil {
+			return opts, fmt.Errorf("cursor is invalid or was issued for a different sort")
+		}
+		opts.After = cursor
+	}
+	return opts, nil
+}
+
+// GetAllResources returns one page of the resources the caller owns or that are
+// shared with them, filtered and sorted by the query parameters
 func GetAllResources(c *gin.Context) {
+	listResources(c, false)
+}
+
+// ListTrash returns one page of the deleted resources the caller can see, taking
+// the same query parameters as GetAllResources
+func ListTrash(c *gin.Context) {
+	listResources(c, true)
+}
+
+func listResources(c *gin.Context, deleted bool) {
 	// Extract user context from headers (set by API Gateway)
 	userID := c.GetHeader("X-User-ID")
 	userEmail := c.GetHeader("X-User-Email")
 	userScopes := c.GetHeader("X-User-Scopes")
 
-	// Convert map to slice for response
-	var resourceList []models.Resource
-	for _, resource := range resources {
-		resourceList = append(resourceList, resource)
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.Deleted = deleted
+	viewer, err := currentViewer(c)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	page, err := repo.List(c.Request.Context(), viewer, opts)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	var nextCursor *string
+	if page.NextCursor != nil {
+		encoded := page.NextCursor.Encode()
+		nextCursor = &encoded
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"resources":   resourceList,
-		"user_id":     userID,
-		"user_email":  userEmail,
-		"user_scopes": userScopes,
+		"resources":      page.Resources,
+		"next_cursor":    nextCursor,
+		"total_estimate": page.TotalEstimate,
+		"user_id":        userID,
+		"user_email":     userEmail,
+		"user_scopes":    userScopes,
 	})
 }
 
 // GetResource returns a specific resource by ID
 func GetResource(c *gin.Context) {
-	id := c.Param("id")
-
-	resource, exists := resources[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+	resource, role, ok := authorize(c, models.RoleViewer)
+	if !ok || notModified(c, resource) {
 		return
 	}
 
+	c.Header("ETag", etag(resource))
 	c.JSON(http.StatusOK, gin.H{
 		"resource":    resource,
+		"role":        role,
 		"user_id":     c.GetHeader("X-User-ID"),
 		"user_email":  c.GetHeader("X-User-Email"),
 		"user_scopes": c.GetHeader("X-User-Scopes"),
@@ -132,20 +225,23 @@ func CreateResource(c *gin.Context) {
 		return
 	}
 
-	// Generate unique ID
-	id := uuid.New().String()
 	now := time.Now()
-
 	resource := models.Resource{
-		ID:          id,
+		ID:          uuid.New().String(),
+		OwnerID:     middleware.CurrentUser(c).ID,
 		Name:        req.Name,
 		Description: req.Description,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Version:     1,
 	}
 
-	resources[id] = resource
+	if err := repo.Create(c.Request.Context(), &resource, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
 
+	c.Header("ETag", etag(&resource))
 	c.JSON(http.StatusCreated, gin.H{
 		"resource":    resource,
 		"user_id":     c.GetHeader("X-User-ID"),
@@ -156,12 +252,9 @@ func CreateResource(c *gin.Context) {
 
 // UpdateResource updates an existing resource
 func UpdateResource(c *gin.Context) {
-	id := c.Param("id")
-
-	// Check if resource exists
-	existingResource, exists := resources[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+	// Check the resource exists and the caller may edit it
+	existingResource, _, ok := authorize(c, models.RoleEditor)
+	if !ok || !requireIfMatch(c, existingResource) {
 		return
 	}
 
@@ -180,8 +273,12 @@ func UpdateResource(c *gin.Context) {
 	}
 	existingResource.UpdatedAt = time.Now()
 
-	resources[id] = existingResource
+	if err := repo.Update(c.Request.Context(), existingResource, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
 
+	c.Header("ETag", etag(existingResource))
 	c.JSON(http.StatusOK, gin.H{
 		"resource":    existingResource,
 		"user_id":     c.GetHeader("X-User-ID"),
@@ -190,22 +287,21 @@ func UpdateResource(c *gin.Context) {
 	})
 }
 
-// DeleteResource deletes a resource
+// DeleteResource moves a resource to the trash, keeping its shares for an
+// undelete; it needs the owner role
 func DeleteResource(c *gin.Context) {
-	id := c.Param("id")
-
-	// Check if resource exists
-	_, exists := resources[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+	resource, _, ok := authorize(c, models.RoleOwner)
+	if !ok || !requireIfMatch(c, resource) {
 		return
 	}
 
-	// Delete resource
-	delete(resources, id)
+	if err := repo.Delete(c.Request.Context(), resource.ID, resource.Version, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Resource deleted successfully",
+		"message":     "Resource moved to trash",
 		"user_id":     c.GetHeader("X-User-ID"),
 		"user_email":  c.GetHeader("X-User-Email"),
 		"user_scopes": c.GetHeader("X-User-Scopes"),
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"resource-service/middleware"
+	"resource-service/models"
+	"resource-service/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListShares returns who a resource is shared with; it needs the owner role
+func ListShares(c *gin.Context) {
+	resource, _, ok := authorize(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	shares, err := repo.Shares(c.Request.Context(), resource.ID)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"resource_id": resource.ID,
+		"owner_id":    resource.OwnerID,
+		"shares":      shares,
+	})
+}
+
+// ShareResource grants a user or group a role on a resource, replacing any role
+// they already had; it needs the owner role
+func ShareResource(c *gin.Context) {
+	resource, _, ok := authorize(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var req models.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	switch req.PrincipalType {
+	case models.PrincipalUser:
+		if req.PrincipalID == resource.OwnerID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The resource owner already has full access"})
+			return
+		}
+	case models.PrincipalGroup:
+		if _, err := groups.Group(c.Request.Context(), req.PrincipalID); err != nil {
+			if errors.Is(err, repository.ErrGroupNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown group"})
+				return
+			}
+			respondRepoError(c, err)
+			return
+		}
+	}
+
+	share := models.ResourceShare{
+		ResourceID:    resource.ID,
+		PrincipalType: req.PrincipalType,
+		PrincipalID:   req.PrincipalID,
+		Role:          req.Role,
+		GrantedBy:     middleware.CurrentUser(c).ID,
+	}
+	if err := repo.Share(c.Request.Context(), &share); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"share": share})
+}
+
+// UnshareResource revokes a user's or group's role on a resource; it needs the owner role
+func UnshareResource(c *gin.Context) {
+	resource, _, ok := authorize(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	principalType, principalID := c.Param("type"), c.Param("principal")
+	err := repo.Unshare(c.Request.Context(), resource.ID, principalType, principalID, middleware.CurrentUser(c).ID)
+	if errors.Is(err, repository.ErrShareNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource is not shared with this " + principalType})
+		return
+	}
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share removed successfully"})
+}
+
+// GetACLEvents returns the sharing history of a resource; it needs the owner role
+func GetACLEvents(c *gin.Context) {
+	resource, _, ok := authorize(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	events, err := repo.ACLEvents(c.Request.Context(), resource.ID)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resource_id": resource.ID, "events": events})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"resource-service/middleware"
+	"resource-service/models"
+	"resource-service/repository"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadVersion loads the history entry named by the :v parameter of a resource,
+// answering 400 for a malformed version and 404 for an unknown one
+func loadVersion(c *gin.Context, resource *models.Resource) (*models.ResourceVersion, bool) {
+	number, err := strconv.ParseInt(c.Param("v"), 10, 64)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version must be a positive integer"})
+		return nil, false
+	}
+	version, err := repo.Version(c.Request.Context(), resource.ID, number)
+	if errors.Is(err, repository.ErrResourceVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return nil, false
+	}
+	if err != nil {
+		respondRepoError(c, err)
+		return nil, false
+	}
+	return version, true
+}
+
+// getWithTrash is repo.Get that also finds deleted resources for their owners,
+// so an owner can read the history before deciding to undelete. Everyone else
+// gets the usual 404 for a resource in the trash.
+func getWithTrash(ctx context.Context, viewer repository.Viewer, id string) (*models.Resource, models.Role, error) {
+	resource, role, err := repo.Get(ctx, viewer, id)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return resource, role, err
+	}
+	resource, role, err = repo.GetDeleted(ctx, viewer, id)
+	if err == nil && !role.Includes(models.RoleOwner) {
+		return nil, "", repository.ErrNotFound
+	}
+	return resource, role, err
+}
+
+// ListVersions returns a resource's history, oldest first; it needs the viewer
+// role, or the owner role once the resource is in the trash
+func ListVersions(c *gin.Context) {
+	resource, _, ok := authorizeFrom(c, models.RoleViewer, getWithTrash)
+	if !ok {
+		return
+	}
+
+	versions, err := repo.Versions(c.Request.Context(), resource.ID)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"resource_id":     resource.ID,
+		"current_version": resource.Version,
+		"versions":        versions,
+	})
+}
+
+// GetVersion returns one snapshot from a resource's history; access is the same
+// as for ListVersions
+func GetVersion(c *gin.Context) {
+	resource, _, ok := authorizeFrom(c, models.RoleViewer, getWithTrash)
+	if !ok {
+		return
+	}
+	version, ok := loadVersion(c, resource)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
+
+// RestoreVersion copies the name and description of an earlier version onto a
+// resource, as a new version. Like any update it needs the editor role and If-Match.
+func RestoreVersion(c *gin.Context) {
+	resource, _, ok := authorize(c, models.RoleEditor)
+	if !ok || !requireIfMatch(c, resource) {
+		return
+	}
+	version, ok := loadVersion(c, resource)
+	if !ok {
+		return
+	}
+
+	resource.Name = version.Name
+	resource.Description = version.Description
+	resource.UpdatedAt = time.Now()
+	if err := repo.Restore(c.Request.Context(), resource, version.Version, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(resource))
+	c.JSON(http.StatusOK, gin.H{
+		"resource":      resource,
+		"restored_from": version.Version,
+		"user_id":       c.GetHeader("X-User-ID"),
+		"user_email":    c.GetHeader("X-User-Email"),
+		"user_scopes":   c.GetHeader("X-User-Scopes"),
+	})
+}
+
+// UndeleteResource takes a resource out of the trash; it needs the owner role.
+// No If-Match is needed, since nobody can change a resource while it is deleted.
+func UndeleteResource(c *gin.Context) {
+	resource, _, ok := authorizeDeleted(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := repo.Undelete(c.Request.Context(), resource, middleware.CurrentUser(c).ID); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(resource))
+	c.JSON(http.StatusOK, gin.H{
+		"resource":    resource,
+		"user_id":     c.GetHeader("X-User-ID"),
+		"user_email":  c.GetHeader("X-User-Email"),
+		"user_scopes": c.GetHeader("X-User-Scopes"),
+	})
+}
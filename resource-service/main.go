@@ -8,6 +8,8 @@ import (
 	"os/signal"
 	"resource-service/config"
 	"resource-service/handlers"
+	"resource-service/middleware"
+	"resource-service/repository"
 	"strconv"
 	"syscall"
 	"time"
@@ -15,48 +17,87 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// gracefulShutdown handles OS signals and cleans up resources
-func gracefulShutdown(srv *http.Server, cleanupStop chan struct{}, cleanupDone chan struct{}) {
+// gracefulShutdown lets in-flight requests finish, then closes the database they use
+func gracefulShutdown(srv *http.Server) {
 	log.Println("Shutting down Resource API server...")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
 	}
-	close(cleanupStop)
-	<-cleanupDone
+	config.CloseDatabaseConnection()
 }
 
 func main() {
 	// Initialize config
 	config.InitConfig()
 
+	// Initialize resource storage
+	if config.AppConfig.ResourceStore == config.StoreMemory {
+		log.Println("Using in-memory resource store; resources are lost on restart")
+		store := repository.NewMemoryRepository()
+		handlers.UseRepository(store, store)
+	} else {
+		if err := config.InitDatabase(); err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		store := repository.NewPostgresRepository(config.DB)
+		handlers.UseRepository(store, store)
+	}
+
 	// Setup Gin router and register routes
 	r := gin.Default()
 
-	// Resource CRUD routes
-	r.GET("/resources", handlers.GetAllResources)
-	r.GET("/resources/:id", handlers.GetResource)
-	r.POST("/resources", handlers.CreateResource)
-	r.PUT("/resources/:id", handlers.UpdateResource)
-	r.DELETE("/resources/:id", handlers.DeleteResource)
+	// Resource CRUD routes, limited to resources the caller owns or that are
+	// shared with them unless they are an admin
+	resources := r.Group("/resources", middleware.UserContextMiddleware())
+	resources.GET("", handlers.GetAllResources)
+	resources.GET("/:id", handlers.GetResource)
+	resources.POST("", handlers.CreateResource)
+	resources.PUT("/:id", handlers.UpdateResource)
+	resources.PATCH("/:id", handlers.PatchResource)
+	resources.DELETE("/:id", handlers.DeleteResource)
+
+	// Version history and trash routes
+	resources.GET("/:id/versions", handlers.ListVersions)
+	resources.GET("/:id/versions/:v", handlers.GetVersion)
+	resources.POST("/:id/versions/:v/restore", handlers.RestoreVersion)
+	resources.GET("/trash", handlers.ListTrash)
+	resources.POST("/:id/undelete", handlers.UndeleteResource)
+
+	// Sharing routes, for the resource's owners
+	resources.GET("/:id/shares", handlers.ListShares)
+	resources.POST("/:id/shares", handlers.ShareResource)
+	resources.DELETE("/:id/shares/:type/:principal", handlers.UnshareResource)
+	resources.GET("/:id/acl-events", handlers.GetACLEvents)
+
+	// Group routes; resources can be shared with every member of a group
+	groupRoutes := r.Group("/groups", middleware.UserContextMiddleware())
+	groupRoutes.GET("", handlers.ListGroups)
+	groupRoutes.POST("", handlers.CreateGroup)
+	groupRoutes.GET("/:id", handlers.GetGroup)
+	groupRoutes.POST("/:id/members", handlers.AddGroupMember)
+	groupRoutes.DELETE("/:id/members/:user_id", handlers.RemoveGroupMember)
+	groupRoutes.GET("/:id/events", handlers.GetGroupEvents)
 
 	srv := &http.Server{
 		Addr:    ":" + strconv.Itoa(config.AppConfig.ResourceServicePort),
 		Handler: r,
 	}
 
-	// Listen for shutdown signals in a goroutine
+	// Listen for shutdown signals in a goroutine; main returns once it is done
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	shutdownDone := make(chan struct{})
 	go func() {
 		<-quit
-		gracefulShutdown(srv, make(chan struct{}), make(chan struct{}))
-		os.Exit(0)
+		gracefulShutdown(srv)
+		close(shutdownDone)
 	}()
 
 	log.Println("Resource API server running on :" + strconv.Itoa(config.AppConfig.ResourceServicePort))
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("listen: %s\n", err)
 	}
+	<-shutdownDone
 }
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"resource-service/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userContextKey = "user"
+
+// UserContextMiddleware reads the caller from the X-User-* headers set by the
+// API Gateway and rejects requests without a user ID
+func UserContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader("X-User-ID")
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+			return
+		}
+
+		var scopes []string
+		if raw := c.GetHeader("X-User-Scopes"); raw != "" {
+			scopes = strings.Split(raw, ",")
+		}
+		c.Set(userContextKey, models.User{ID: userID, Email: c.GetHeader("X-User-Email"), Scopes: scopes})
+		c.Next()
+	}
+}
+
+// CurrentUser returns the caller stored by UserContextMiddleware
+func CurrentUser(c *gin.Context) models.User {
+	user, _ := c.MustGet(userContextKey).(models.User)
+	return user
+}
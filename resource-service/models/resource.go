@@ -5,11 +5,23 @@ import (
 )
 
 type Resource struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	OwnerID     string    `gorm:"index;not null;default:''" json:"owner_id"`
+	Name        string    `gorm:"not null" json:"name"`
 	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"index" json:"updated_at"`
+	// Version starts at 1 and goes up with every change, including deletes and
+	// restores; it is the resource's ETag
+	Version int64 `gorm:"not null;default:1" json:"version"`
+	// DeletedAt is set while the resource is in the trash
+	DeletedAt *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy string     `gorm:"type:varchar(64)" json:"deleted_by,omitempty"`
+}
+
+// TableName returns the database table name for the Resource model
+func (Resource) TableName() string {
+	return "resources"
 }
 
 type CreateResourceRequest struct {
@@ -21,4 +33,3 @@ type UpdateResourceRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
- 
\ No newline at end of file
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// Role is a level of access to a resource. Each role includes the ones below it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // read
+	RoleEditor Role = "editor" // read and update
+	RoleOwner  Role = "owner"  // everything, including delete and sharing
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Includes reports whether r grants at least the access of other
+func (r Role) Includes(other Role) bool {
+	return roleRank[r] >= roleRank[other] && roleRank[other] > 0
+}
+
+// Max returns the stronger of two roles; the empty role is the weakest
+func (r Role) Max(other Role) Role {
+	if roleRank[other] > roleRank[r] {
+		return other
+	}
+	return r
+}
+
+// Principals a resource can be shared with
+const (
+	PrincipalUser  = "user"
+	PrincipalGroup = "group"
+)
+
+// ResourceShare grants a user, or every member of a group, a role on a resource
+type ResourceShare struct {
+	ID            uint      `gorm:"primarykey" json:"-"`
+	ResourceID    string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_resource_share_principal" json:"resource_id"`
+	PrincipalType string    `gorm:"type:varchar(8);not null;uniqueIndex:idx_resource_share_principal;index:idx_resource_share_lookup" json:"principal_type"`
+	PrincipalID   string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_resource_share_principal;index:idx_resource_share_lookup" json:"principal_id"`
+	Role          Role      `gorm:"type:varchar(8);not null" json:"role"`
+	GrantedBy     string    `gorm:"type:varchar(64);not null" json:"granted_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName returns the database table name for the ResourceShare model
+func (ResourceShare) TableName() string {
+	return "resource_shares"
+}
+
+// Group is a named set of users that resources can be shared with
+type Group struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	OwnerID   string    `gorm:"type:varchar(64);index;not null" json:"owner_id"`
+	Members   []string  `gorm:"-" json:"members,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the database table name for the Group model
+func (Group) TableName() string {
+	return "resource_groups"
+}
+
+// GroupMember puts a user in a group
+type GroupMember struct {
+	GroupID   string `gorm:"primaryKey;type:varchar(36)"`
+	UserID    string `gorm:"primaryKey;type:varchar(64);index"`
+	CreatedAt time.Time
+}
+
+// TableName returns the database table name for the GroupMember model
+func (GroupMember) TableName() string {
+	return "resource_group_members"
+}
+
+// ACL event actions
+const (
+	ACLShare        = "share"
+	ACLUnshare      = "unshare"
+	ACLMemberAdd    = "group_member_add"
+	ACLMemberRemove = "group_member_remove"
+)
+
+// ACLEvent records one change to who can access what. Events outlive the
+// resources and groups they mention.
+type ACLEvent struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	Action        string    `gorm:"type:varchar(24);not null" json:"action"`
+	ResourceID    string    `gorm:"type:varchar(36);index" json:"resource_id,omitempty"`
+	GroupID       string    `gorm:"type:varchar(36);index" json:"group_id,omitempty"`
+	PrincipalType string    `gorm:"type:varchar(8)" json:"principal_type,omitempty"`
+	PrincipalID   string    `gorm:"type:varchar(64)" json:"principal_id,omitempty"`
+	Role          Role      `gorm:"type:varchar(8)" json:"role,omitempty"`
+	ActorID       string    `gorm:"type:varchar(64);not null" json:"actor_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName returns the database table name for the ACLEvent model
+func (ACLEvent) TableName() string {
+	return "acl_events"
+}
+
+type ShareRequest struct {
+	PrincipalType string `json:"principal_type" binding:"required,oneof=user group"`
+	PrincipalID   string `json:"principal_id" binding:"required,max=64"`
+	Role          Role   `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type GroupMemberRequest struct {
+	UserID string `json:"user_id" binding:"required,max=64"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "strings"
+
+// ScopeAdmin grants access to every user's resources
+const ScopeAdmin = "admin"
+
+// User is the caller as identified by the API Gateway's X-User-* headers
+type User struct {
+	ID     string
+	Email  string
+	Scopes []string
+}
+
+// IsAdmin reports whether the user holds the admin scope
+func (u User) IsAdmin() bool {
+	for _, scope := range u.Scopes {
+		if strings.TrimSpace(scope) == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Version history actions
+const (
+	VersionCreate   = "create"
+	VersionUpdate   = "update"
+	VersionRestore  = "restore"
+	VersionDelete   = "delete"
+	VersionUndelete = "undelete"
+)
+
+// FieldChange is the old and new value of one field in a version's diff
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ResourceVersion is a snapshot of a resource after one change. Its Version
+// matches the resource's version, and so its ETag, at the time.
+type ResourceVersion struct {
+	ID          uint   `gorm:"primarykey" json:"-"`
+	ResourceID  string `gorm:"type:varchar(36);not null;uniqueIndex:idx_resource_version" json:"resource_id"`
+	Version     int64  `gorm:"not null;uniqueIndex:idx_resource_version" json:"version"`
+	Action      string `gorm:"type:varchar(16);not null" json:"action"`
+	Name        string `gorm:"not null" json:"name"`
+	Description string `json:"description"`
+	// Diff maps each field the change touched to its old and new value; it is
+	// empty for deletes and undeletes
+	Diff map[string]FieldChange `gorm:"serializer:json" json:"diff"`
+	// RestoredFrom is the version a restore copied
+	RestoredFrom int64     `json:"restored_from,omitempty"`
+	ActorID      string    `gorm:"type:varchar(64);not null" json:"actor_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName returns the database table name for the ResourceVersion model
+func (ResourceVersion) TableName() string {
+	return "resource_versions"
+}
@@ -0,0 +1,324 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902)
+// documents to decoded JSON values: map[string]interface{}, []interface{},
+// string, float64, bool and nil.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for a patch document that is malformed
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrCannotApply is returned when a JSON Patch operation names a location
+	// that does not exist or cannot hold a value
+	ErrCannotApply = errors.New("patch cannot be applied")
+	// ErrTestFailed is returned when a JSON Patch test operation does not match
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to doc and returns the result.
+// doc is not modified.
+func MergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return merge(deepCopy(doc), p), nil
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = merge(t[key], value)
+		}
+	}
+	return t
+}
+
+// Operation is one step of a JSON Patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  *string         `json:"from"` // nil when absent; "" is the whole document
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch to doc and returns the result.
+// Operations apply in order and the patch is all or nothing; doc is not modified.
+func JSONPatch(doc interface{}, patch []byte) (interface{}, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: expected an array of operations: %v", ErrInvalidPatch, err)
+	}
+
+	result := deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		if result, err = apply(result, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return result, nil
+}
+
+// apply runs one operation against doc, which it may modify
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: %q needs a value", ErrInvalidPatch, op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: %q needs a from", ErrInvalidPatch, op.Op)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isProperPrefix(from, path) {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+			doc, value, err := remove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses an array reference token for an array of length n. With
+// end set, "-" and n name the position after the last element.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if end && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrCannotApply, token)
+	}
+	if i > n || (i == n && !end) {
+		return 0, fmt.Errorf("%w: index %d is out of range", ErrCannotApply, i)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q does not exist", ErrCannotApply, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is inside a value that is not an object or array", ErrCannotApply, token)
+		}
+	}
+	return doc, nil
+}
+
+// update walks doc to the container holding the last token of path, replaces
+// that container with change's result and returns the new document
+func update(doc interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(doc, path[0])
+	}
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q does not exist", ErrCannotApply, path[0])
+		}
+		child, err := update(child, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = child
+		return container, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := update(container[i], path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[i] = child
+		return container, nil
+	}
+	return nil, fmt.Errorf("%w: %q is inside a value that is not an object or array", ErrCannotApply, path[0])
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: cannot add %q to a value that is not an object or array", ErrCannotApply, token)
+	})
+}
+
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, _ := arrayIndex(token, len(c), false)
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: cannot replace %q", ErrCannotApply, token)
+	})
+}
+
+// remove deletes the value at path and returns the new document and the removed value
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrCannotApply)
+	}
+	removed, err := get(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err = update(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, _ := arrayIndex(token, len(c), false)
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: cannot remove %q", ErrCannotApply, token)
+	})
+	return doc, removed, err
+}
+
+// deepCopy copies a decoded JSON value so patching it leaves the original untouched
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c[key] = deepCopy(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = deepCopy(item)
+		}
+		return c
+	}
+	return value
+}
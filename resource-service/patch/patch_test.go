@@ -0,0 +1,400 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+// TestMergePatchRFC7396 runs the examples of RFC 7396 Appendix A
+func TestMergePatchRFC7396(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doc+" + "+tt.patch, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got, err := MergePatch(doc, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+				t.Errorf("MergePatch modified its input: %v", doc)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	_, err := MergePatch(map[string]interface{}{}, []byte(`{"a":`))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("err = %v, want ErrInvalidPatch", err)
+	}
+}
+
+type jsonPatchCase struct {
+	name  string
+	doc   string
+	patch string
+	want  string // expected document when err is nil
+	err   error  // expected error, or nil
+}
+
+func runJSONPatch(t *testing.T, tests []jsonPatchCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got, err := JSONPatch(doc, []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("JSONPatch: %v", err)
+				}
+				if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			}
+			// All or nothing, and never in place
+			if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+				t.Errorf("JSONPatch modified its input: %v", doc)
+			}
+		})
+	}
+}
+
+// TestJSONPatchRFC6902 runs the examples of RFC 6902 Appendix A
+func TestJSONPatchRFC6902(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "A.8 testing a value: success",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:  "A.9 testing a value: error",
+			doc:   `{"baz":"qux"}`,
+			patch: `[{"op":"test","path":"/baz","value":"bar"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			want:  `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:  "A.12 adding to a nonexistent target",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			// encoding/json keeps the last "op", so this is a remove of a missing member
+			name:  "A.13 invalid JSON Patch document",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":10}]`,
+			want:  `{"/":9,"~1":10}`,
+		},
+		{
+			name:  "A.15 comparing strings and numbers",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":"10"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+	})
+}
+
+func TestJSONPatchPointers(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{
+			name:  "~1 unescapes to /",
+			doc:   `{"a/b":1}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":2}]`,
+			want:  `{"a/b":2}`,
+		},
+		{
+			name:  "~0 unescapes to ~",
+			doc:   `{"m~n":1}`,
+			patch: `[{"op":"remove","path":"/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "empty key",
+			doc:   `{"":1}`,
+			patch: `[{"op":"test","path":"/","value":1}]`,
+			want:  `{"":1}`,
+		},
+		{
+			name:  "whole document",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"","value":{"b":2}}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "pointer without leading slash",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"a"}]`,
+			err:   ErrInvalidPatch,
+		},
+	})
+}
+
+func TestJSONPatchArrayIndexes(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{
+			name:  "add with - appends",
+			doc:   `[1,2]`,
+			patch: `[{"op":"add","path":"/-","value":3}]`,
+			want:  `[1,2,3]`,
+		},
+		{
+			name:  "add at the length appends",
+			doc:   `[1,2]`,
+			patch: `[{"op":"add","path":"/2","value":3}]`,
+			want:  `[1,2,3]`,
+		},
+		{
+			name:  "add past the length",
+			doc:   `[1,2]`,
+			patch: `[{"op":"add","path":"/3","value":3}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "add with a leading zero",
+			doc:   `[1,2]`,
+			patch: `[{"op":"add","path":"/01","value":3}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "index 0 is not a leading zero",
+			doc:   `[1,2]`,
+			patch: `[{"op":"replace","path":"/0","value":0}]`,
+			want:  `[0,2]`,
+		},
+		{
+			name:  "negative index",
+			doc:   `[1,2]`,
+			patch: `[{"op":"replace","path":"/-1","value":0}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "replace with -",
+			doc:   `[1,2]`,
+			patch: `[{"op":"replace","path":"/-","value":3}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "test with -",
+			doc:   `[1,2]`,
+			patch: `[{"op":"test","path":"/-","value":2}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			// remove and replace discard arrayIndex errors because get has already
+			// validated the token; these make sure that stays true
+			name:  "remove with a leading zero",
+			doc:   `{"foo":[1,2]}`,
+			patch: `[{"op":"remove","path":"/foo/01"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "remove with -",
+			doc:   `{"foo":[1,2]}`,
+			patch: `[{"op":"remove","path":"/foo/-"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "remove at the length",
+			doc:   `{"foo":[1,2]}`,
+			patch: `[{"op":"remove","path":"/foo/2"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "replace at the length",
+			doc:   `{"foo":[1,2]}`,
+			patch: `[{"op":"replace","path":"/foo/2","value":3}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "index into a string",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/foo/0","value":"x"}]`,
+			err:   ErrCannotApply,
+		},
+	})
+}
+
+func TestJSONPatchMoveAndCopy(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{
+			name:  "move into its own child",
+			doc:   `{"a":{"b":{}}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move onto itself",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a"}]`,
+			want:  `{"a":{"b":1}}`,
+		},
+		{
+			name:  "move to a sibling with a shared prefix",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"move","from":"/a","path":"/ab"}]`,
+			want:  `{"ab":1}`,
+		},
+		{
+			name:  "move from a missing location",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"move","from":"/b","path":"/c"}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "copy is deep",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+	})
+}
+
+func TestJSONPatchTest(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{
+			name:  "objects compare deeply, ignoring key order",
+			doc:   `{"a":{"x":[1,{"y":null}],"z":true}}`,
+			patch: `[{"op":"test","path":"/a","value":{"z":true,"x":[1,{"y":null}]}}]`,
+			want:  `{"a":{"x":[1,{"y":null}],"z":true}}`,
+		},
+		{
+			name:  "arrays compare in order",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"test","path":"/a","value":[2,1]}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "null is not a missing value",
+			doc:   `{"a":null}`,
+			patch: `[{"op":"test","path":"/a","value":null}]`,
+			want:  `{"a":null}`,
+		},
+		{
+			name:  "test of a missing member",
+			doc:   `{}`,
+			patch: `[{"op":"test","path":"/a","value":null}]`,
+			err:   ErrCannotApply,
+		},
+		{
+			name:  "failed test undoes earlier operations",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			err:   ErrTestFailed,
+		},
+	})
+}
+
+func TestJSONPatchInvalid(t *testing.T) {
+	runJSONPatch(t, []jsonPatchCase{
+		{name: "not an array", doc: `{}`, patch: `{"op":"add"}`, err: ErrInvalidPatch},
+		{name: "unknown op", doc: `{}`, patch: `[{"op":"frobnicate","path":"/a"}]`, err: ErrInvalidPatch},
+		{name: "add without value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, err: ErrInvalidPatch},
+		{name: "move without from", doc: `{"a":1}`, patch: `[{"op":"move","path":"/b"}]`, err: ErrInvalidPatch},
+		{name: "copy without from", doc: `{"a":1}`, patch: `[{"op":"copy","path":"/b"}]`, err: ErrInvalidPatch},
+		{name: "remove the whole document", doc: `{"a":1}`, patch: `[{"op":"remove","path":""}]`, err: ErrCannotApply},
+	})
+}
@@ -0,0 +1,453 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"resource-service/models"
+	"testing"
+	"time"
+)
+
+// store is what the service needs from a backend: resources and groups
+type store interface {
+	ResourceRepository
+	GroupRepository
+}
+
+// testContract runs the behaviour every store must share against fresh stores from newStore
+func testContract(t *testing.T, newStore func(t *testing.T) store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s store)
+	}{
+		{"GetHidesOthersResources", testGetHidesOthersResources},
+		{"UpdateIsConditional", testUpdateIsConditional},
+		{"HistoryRecordsEveryWrite", testHistoryRecordsEveryWrite},
+		{"DeleteMovesToTrash", testDeleteMovesToTrash},
+		{"SharesGrantRoles", testSharesGrantRoles},
+		{"GroupMembership", testGroupMembership},
+		{"ListPagesWithCursor", testListPagesWithCursor},
+		{"ListFilters", testListFilters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore(t))
+		})
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testContract(t, func(t *testing.T) store { return NewMemoryRepository() })
+}
+
+var (
+	ctx   = context.Background()
+	base  = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	alice = Viewer{UserID: "alice"}
+	bob   = Viewer{UserID: "bob"}
+	admin = Viewer{UserID: "root", Admin: true}
+)
+
+// create stores a resource owned by owner, created n minutes after base
+func create(t *testing.T, s store, id, owner, name string, n int) *models.Resource {
+	t.Helper()
+	at := base.Add(time.Duration(n) * time.Minute)
+	resource := &models.Resource{ID: id, OwnerID: owner, Name: name, CreatedAt: at, UpdatedAt: at, Version: 1}
+	if err := s.Create(ctx, resource, owner); err != nil {
+		t.Fatalf("Create %s: %v", id, err)
+	}
+	return resource
+}
+
+func wantErr(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: err = %v, want %v", what, err, want)
+	}
+}
+
+func wantRole(t *testing.T, s store, viewer Viewer, id string, want models.Role) {
+	t.Helper()
+	_, role, err := s.Get(ctx, viewer, id)
+	if want == "" {
+		wantErr(t, "Get by "+viewer.UserID, err, ErrNotFound)
+		return
+	}
+	if err != nil {
+		t.Fatalf("Get by %s: %v", viewer.UserID, err)
+	}
+	if role != want {
+		t.Fatalf("role of %s = %q, want %q", viewer.UserID, role, want)
+	}
+}
+
+func testGetHidesOthersResources(t *testing.T, s store) {
+	create(t, s, "r1", "alice", "Report", 0)
+
+	wantRole(t, s, alice, "r1", models.RoleOwner)
+	wantRole(t, s, admin, "r1", models.RoleOwner)
+	wantRole(t, s, bob, "r1", "")
+
+	_, _, err := s.Get(ctx, alice, "missing")
+	wantErr(t, "Get missing", err, ErrNotFound)
+	_, _, err = s.GetDeleted(ctx, alice, "r1")
+	wantErr(t, "GetDeleted of a live resource", err, ErrNotFound)
+}
+
+func testUpdateIsConditional(t *testing.T, s store) {
+	resource := create(t, s, "r1", "alice", "Report", 0)
+
+	resource.Name = "Report v2"
+	if err := s.Update(ctx, resource, "alice"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if resource.Version != 2 {
+		t.Fatalf("version after update = %d, want 2", resource.Version)
+	}
+
+	stale := *resource
+	stale.Version = 1
+	stale.Name = "Lost update"
+	wantErr(t, "Update with a stale version", s.Update(ctx, &stale, "bob"), ErrVersionConflict)
+
+	got, _, err := s.Get(ctx, alice, "r1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Report v2" || got.Version != 2 {
+		t.Fatalf("stored %q at version %d, want Report v2 at 2", got.Name, got.Version)
+	}
+
+	missing := models.Resource{ID: "missing", Version: 1}
+	wantErr(t, "Update missing", s.Update(ctx, &missing, "alice"), ErrVersionConflict)
+}
+
+func testHistoryRecordsEveryWrite(t *testing.T, s store) {
+	resource := create(t, s, "r1", "alice", "Draft", 0)
+	resource.Name = "Final"
+	resource.Description = "Done"
+	if err := s.Update(ctx, resource, "bob"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	resource.Name = "Draft"
+	resource.Description = ""
+	if err := s.Restore(ctx, resource, 1, "alice"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	versions, err := s.Versions(ctx, "r1")
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("got %d versions, want 3", len(versions))
+	}
+	wantActions := []string{models.VersionCreate, models.VersionUpdate, models.VersionRestore}
+	for i, v := range versions {
+		if v.Version != int64(i+1) || v.Action != wantActions[i] {
+			t.Errorf("version %d = %d %s, want %d %s", i, v.Version, v.Action, i+1, wantActions[i])
+		}
+	}
+
+	update := versions[1]
+	if update.ActorID != "bob" || update.Name != "Final" {
+		t.Errorf("update snapshot = %s by %s, want Final by bob", update.Name, update.ActorID)
+	}
+	if change := update.Diff["name"]; change.From != "Draft" || change.To != "Final" {
+		t.Errorf("name diff = %+v, want Draft → Final", change)
+	}
+	if change := update.Diff["description"]; change.From != "" || change.To != "Done" {
+		t.Errorf("description diff = %+v, want \"\" → Done", change)
+	}
+	if versions[2].RestoredFrom != 1 {
+		t.Errorf("restore RestoredFrom = %d, want 1", versions[2].RestoredFrom)
+	}
+
+	v, err := s.Version(ctx, "r1", 2)
+	if err != nil || v.Name != "Final" {
+		t.Fatalf("Version 2 = %+v, %v", v, err)
+	}
+	_, err = s.Version(ctx, "r1", 9)
+	wantErr(t, "Version 9", err, ErrResourceVersionNotFound)
+}
+
+func testDeleteMovesToTrash(t *testing.T, s store) {
+	resource := create(t, s, "r1", "alice", "Report", 0)
+	share := &models.ResourceShare{ResourceID: "r1", PrincipalType: models.PrincipalUser, PrincipalID: "bob", Role: models.RoleViewer, GrantedBy: "alice"}
+	if err := s.Share(ctx, share); err != nil {
+		t.Fatalf("Share: %v", err)
+	}
+
+	wantErr(t, "Delete with a stale version", s.Delete(ctx, "r1", 7, "alice"), ErrVersionConflict)
+	if err := s.Delete(ctx, "r1", resource.Version, "alice"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, _, err := s.Get(ctx, alice, "r1")
+	wantErr(t, "Get deleted", err, ErrNotFound)
+	deleted, role, err := s.GetDeleted(ctx, alice, "r1")
+	if err != nil {
+		t.Fatalf("GetDeleted: %v", err)
+	}
+	if role != models.RoleOwner || deleted.DeletedAt == nil || deleted.DeletedBy != "alice" || deleted.Version != 2 {
+		t.Fatalf("trashed resource = %+v with role %s", deleted, role)
+	}
+	wantErr(t, "Update deleted", s.Update(ctx, deleted, "alice"), ErrVersionConflict)
+	wantErr(t, "Delete twice", s.Delete(ctx, "r1", deleted.Version, "alice"), ErrVersionConflict)
+
+	live, err := s.List(ctx, alice, ListOptions{Limit: 10})
+	if err != nil || len(live.Resources) != 0 {
+		t.Fatalf("live list = %+v, %v; want empty", live, err)
+	}
+	trash, err := s.List(ctx, alice, ListOptions{Deleted: true, Limit: 10})
+	if err != nil || len(trash.Resources) != 1 {
+		t.Fatalf("trash list = %+v, %v; want r1", trash, err)
+	}
+
+	if err := s.Undelete(ctx, deleted, "alice"); err != nil {
+		t.Fatalf("Undelete: %v", err)
+	}
+	if deleted.DeletedAt != nil || deleted.Version != 3 {
+		t.Fatalf("undeleted resource = %+v", deleted)
+	}
+	// Shares survive the trash
+	wantRole(t, s, bob, "r1", models.RoleViewer)
+
+	versions, _ := s.Versions(ctx, "r1")
+	if n := len(versions); n != 3 || versions[1].Action != models.VersionDelete || versions[2].Action != models.VersionUndelete {
+		t.Fatalf("history = %+v, want create, delete, undelete", versions)
+	}
+}
+
+func testSharesGrantRoles(t *testing.T, s store) {
+	create(t, s, "r1", "alice", "Report", 0)
+	group := &models.Group{ID: "g1", Name: "Team", OwnerID: "carol", CreatedAt: base}
+	if err := s.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if err := s.AddMember(ctx, "g1", "bob", "carol"); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	bobInTeam := Viewer{UserID: "bob", Groups: []string{"g1"}}
+
+	share := func(principalType, principalID string, role models.Role) {
+		t.Helper()
+		err := s.Share(ctx, &models.ResourceShare{ResourceID: "r1", PrincipalType: principalType, PrincipalID: principalID, Role: role, GrantedBy: "alice"})
+		if err != nil {
+			t.Fatalf("Share %s %s: %v", principalType, principalID, err)
+		}
+	}
+
+	share(models.PrincipalUser, "bob", models.RoleViewer)
+	wantRole(t, s, bob, "r1", models.RoleViewer)
+
+	// The strongest role among the user's own share and their groups' wins
+	share(models.PrincipalGroup, "g1", models.RoleEditor)
+	wantRole(t, s, bobInTeam, "r1", models.RoleEditor)
+	wantRole(t, s, bob, "r1", models.RoleViewer)
+
+	// Sharing again replaces the role rather than adding a share
+	share(models.PrincipalUser, "bob", models.RoleOwner)
+	wantRole(t, s, bob, "r1", models.RoleOwner)
+	shares, err := s.Shares(ctx, "r1")
+	if err != nil || len(shares) != 2 {
+		t.Fatalf("Shares = %+v, %v; want 2", shares, err)
+	}
+	if shares[0].PrincipalID != "bob" || shares[1].PrincipalID != "g1" {
+		t.Errorf("shares out of order: %s, %s", shares[0].PrincipalID, shares[1].PrincipalID)
+	}
+
+	if err := s.Unshare(ctx, "r1", models.PrincipalUser, "bob", "alice"); err != nil {
+		t.Fatalf("Unshare: %v", err)
+	}
+	wantRole(t, s, bob, "r1", "")
+	wantErr(t, "Unshare twice", s.Unshare(ctx, "r1", models.PrincipalUser, "bob", "alice"), ErrShareNotFound)
+
+	err = s.Share(ctx, &models.ResourceShare{ResourceID: "missing", PrincipalType: models.PrincipalUser, PrincipalID: "bob", Role: models.RoleViewer, GrantedBy: "alice"})
+	wantErr(t, "Share a missing resource", err, ErrNotFound)
+
+	events, err := s.ACLEvents(ctx, "r1")
+	if err != nil {
+		t.Fatalf("ACLEvents: %v", err)
+	}
+	want := []string{"share bob viewer", "share g1 editor", "share bob owner", "unshare bob owner"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d ACL events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if got := fmt.Sprintf("%s %s %s", e.Action, e.PrincipalID, e.Role); got != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func testGroupMembership(t *testing.T, s store) {
+	for i, id := range []string{"g1", "g2"} {
+		group := &models.Group{ID: id, Name: "Group " + id, OwnerID: "alice", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := s.CreateGroup(ctx, group); err != nil {
+			t.Fatalf("CreateGroup %s: %v", id, err)
+		}
+	}
+
+	if err := s.AddMember(ctx, "g1", "bob", "alice"); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if err := s.AddMember(ctx, "g1", "bob", "alice"); err != nil {
+		t.Fatalf("AddMember again: %v", err)
+	}
+	wantErr(t, "AddMember to a missing group", s.AddMember(ctx, "missing", "bob", "alice"), ErrGroupNotFound)
+
+	group, err := s.Group(ctx, "g1")
+	if err != nil {
+		t.Fatalf("Group: %v", err)
+	}
+	if len(group.Members) != 2 || !contains(group.Members, "alice") || !contains(group.Members, "bob") {
+		t.Errorf("members = %v, want alice and bob", group.Members)
+	}
+	_, err = s.Group(ctx, "missing")
+	wantErr(t, "Group missing", err, ErrGroupNotFound)
+
+	ids, err := s.GroupsOf(ctx, "alice")
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("GroupsOf alice = %v, %v; want g1 and g2", ids, err)
+	}
+	groups, err := s.ListGroups(ctx, "alice")
+	if err != nil || len(groups) != 2 || groups[0].ID != "g1" || groups[1].ID != "g2" {
+		t.Fatalf("ListGroups alice = %+v, %v; want g1, g2", groups, err)
+	}
+
+	if err := s.RemoveMember(ctx, "g1", "bob", "alice"); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	wantErr(t, "RemoveMember twice", s.RemoveMember(ctx, "g1", "bob", "alice"), ErrMemberNotFound)
+	if ids, _ := s.GroupsOf(ctx, "bob"); len(ids) != 0 {
+		t.Errorf("bob still in %v", ids)
+	}
+
+	events, err := s.GroupEvents(ctx, "g1")
+	if err != nil {
+		t.Fatalf("GroupEvents: %v", err)
+	}
+	want := []string{"group_member_add alice", "group_member_add bob", "group_member_remove bob"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d group events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if got := e.Action + " " + e.PrincipalID; got != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func testListPagesWithCursor(t *testing.T, s store) {
+	// r3 and r4 share a timestamp, so the ID breaks the tie
+	for i, id := range []string{"r1", "r2", "r3", "r4", "r5"} {
+		n := i
+		if id == "r4" {
+			n = 2
+		}
+		create(t, s, id, "alice", "Resource "+id, n)
+	}
+	create(t, s, "other", "bob", "Not visible", 9)
+
+	for _, desc := range []bool{false, true} {
+		t.Run(fmt.Sprintf("desc=%v", desc), func(t *testing.T) {
+			want := []string{"r1", "r2", "r3", "r4", "r5"}
+			if desc {
+				want = []string{"r5", "r4", "r3", "r2", "r1"}
+			}
+
+			var got []string
+			opts := ListOptions{Sort: SortCreatedAt, Desc: desc, Limit: 2}
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatal("cursor never ran out")
+				}
+				page, err := s.List(ctx, alice, opts)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if page.TotalEstimate != 5 {
+					t.Errorf("TotalEstimate = %d, want 5", page.TotalEstimate)
+				}
+				for _, r := range page.Resources {
+					got = append(got, r.ID)
+				}
+				if page.NextCursor == nil {
+					break
+				}
+				// The cursor survives encoding, as it does between requests
+				cursor, err := DecodeCursor(page.NextCursor.Encode(), opts.Sort, opts.Desc)
+				if err != nil {
+					t.Fatalf("DecodeCursor: %v", err)
+				}
+				opts.After = cursor
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("paged through %v, want %v", got, want)
+			}
+		})
+	}
+
+	all, err := s.List(ctx, admin, ListOptions{Limit: 10})
+	if err != nil || len(all.Resources) != 6 {
+		t.Fatalf("admin list = %d resources, %v; want 6", len(all.Resources), err)
+	}
+
+	cursor := Cursor{Sort: SortCreatedAt, Value: base, ID: "r1"}
+	_, err = DecodeCursor(cursor.Encode(), SortUpdatedAt, false)
+	wantErr(t, "cursor for another sort", err, ErrInvalidCursor)
+	_, err = DecodeCursor("not a cursor", SortCreatedAt, false)
+	wantErr(t, "garbage cursor", err, ErrInvalidCursor)
+}
+
+func testListFilters(t *testing.T, s store) {
+	create(t, s, "r1", "alice", "Quarterly report", 0)
+	r2 := create(t, s, "r2", "alice", "Team offsite", 1)
+	r2.Description = "Planning for the quarterly offsite"
+	r2.UpdatedAt = base.Add(time.Hour)
+	if err := s.Update(ctx, r2, "alice"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	create(t, s, "r3", "bob", "Bob's report", 2)
+	err := s.Share(ctx, &models.ResourceShare{ResourceID: "r3", PrincipalType: models.PrincipalUser, PrincipalID: "alice", Role: models.RoleViewer, GrantedBy: "bob"})
+	if err != nil {
+		t.Fatalf("Share: %v", err)
+	}
+
+	after := base.Add(30 * time.Minute)
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"owned and shared", ListOptions{}, []string{"r1", "r2", "r3"}},
+		{"shared with me", ListOptions{SharedWithMe: true}, []string{"r3"}},
+		{"name ignores case", ListOptions{Name: "REPORT"}, []string{"r1", "r3"}},
+		{"owner", ListOptions{OwnerID: "bob"}, []string{"r3"}},
+		{"search name and description", ListOptions{Search: "quarterly"}, []string{"r1", "r2"}},
+		{"search needs every word", ListOptions{Search: "quarterly offsite"}, []string{"r2"}},
+		{"updated after", ListOptions{UpdatedAfter: &after}, []string{"r2"}},
+		{"sort by updated", ListOptions{Sort: SortUpdatedAt, Desc: true}, []string{"r2", "r3", "r1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Limit = 10
+			if tt.opts.Sort == "" {
+				tt.opts.Sort = SortCreatedAt
+			}
+			page, err := s.List(ctx, alice, tt.opts)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var got []string
+			for _, r := range page.Resources {
+				got = append(got, r.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
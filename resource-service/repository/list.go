@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"resource-service/models"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was issued for a different sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// totalEstimateCap bounds the rows counted for Page.TotalEstimate, so a broad
+// filter does not cost a full scan
+const totalEstimateCap = 10000
+
+// SortField is a timestamp resources can be ordered by
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortUpdatedAt SortField = "updated_at"
+)
+
+// SortFields lists the fields accepted by ?sort=
+var SortFields = []SortField{SortCreatedAt, SortUpdatedAt}
+
+// of returns the value of the sort field on a resource
+func (f SortField) of(resource models.Resource) time.Time {
+	if f == SortUpdatedAt {
+		return resource.UpdatedAt
+	}
+	return resource.CreatedAt
+}
+
+// ListOptions narrows and pages the resources returned by List. Zero values
+// match everything; ties on the sort field are broken by ID.
+type ListOptions struct {
+	// SharedWithMe keeps only resources shared with the viewer or their groups,
+	// leaving out the ones they own
+	SharedWithMe bool
+	// Deleted lists the trash: resources that were deleted, instead of live ones
+	Deleted bool
+	// Name keeps resources whose name contains it, ignoring case
+	Name    string
+	OwnerID string
+	// Time ranges are exclusive
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// Search is a full-text query over name and description
+	Search string
+
+	Sort SortField
+	Desc bool
+	// Limit is the page size; After continues from a previous page
+	Limit int
+	After *Cursor
+}
+
+// Page is one page of List results
+type Page struct {
+	Resources []models.Resource
+	// NextCursor continues after the last resource, nil on the last page
+	NextCursor *Cursor
+	// TotalEstimate counts every match, ignoring the cursor, up to totalEstimateCap
+	TotalEstimate int64
+}
+
+// Cursor is the position after the last resource of a page: its sort value
+// and ID, with the sort it was issued for
+type Cursor struct {
+	Sort  SortField `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+// cursorAfter returns the cursor following resource under opts' sort
+func cursorAfter(resource models.Resource, opts ListOptions) *Cursor {
+	return &Cursor{Sort: opts.Sort, Desc: opts.Desc, Value: opts.Sort.of(resource), ID: resource.ID}
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor from Encode and checks it was issued for the given sort
+func DecodeCursor(s string, sort SortField, desc bool) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.Desc != desc {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// column returns the resources column for the sort field, created_at unless it is updated_at
+func (f SortField) column() string {
+	if f == SortUpdatedAt {
+		return string(SortUpdatedAt)
+	}
+	return string(SortCreatedAt)
+}
+
+// precedes reports whether position (va, ida) sorts before (vb, idb) under opts
+func (opts ListOptions) precedes(va time.Time, ida string, vb time.Time, idb string) bool {
+	if va.Equal(vb) {
+		if opts.Desc {
+			return ida > idb
+		}
+		return ida < idb
+	}
+	if opts.Desc {
+		return va.After(vb)
+	}
+	return va.Before(vb)
+}
+
+// before reports whether resource a sorts before b under opts
+func (opts ListOptions) before(a, b models.Resource) bool {
+	return opts.precedes(opts.Sort.of(a), a.ID, opts.Sort.of(b), b.ID)
+}
+
+// pastCursor reports whether a resource sorts after opts.After, or there is no cursor
+func (opts ListOptions) pastCursor(resource models.Resource) bool {
+	return opts.After == nil || opts.precedes(opts.After.Value, opts.After.ID, opts.Sort.of(resource), resource.ID)
+}
+
+// matches applies every filter but SharedWithMe to a resource. Search matches
+// when each word of the query appears in the name or description, an
+// approximation of the Postgres full-text search.
+func (opts ListOptions) matches(resource models.Resource) bool {
+	if (resource.DeletedAt != nil) != opts.Deleted {
+		return false
+	}
+	if opts.Name != "" && !strings.Contains(strings.ToLower(resource.Name), strings.ToLower(opts.Name)) {
+		return false
+	}
+	if opts.OwnerID != "" && resource.OwnerID != opts.OwnerID {
+		return false
+	}
+	if !within(resource.CreatedAt, opts.CreatedAfter, opts.CreatedBefore) ||
+		!within(resource.UpdatedAt, opts.UpdatedAfter, opts.UpdatedBefore) {
+		return false
+	}
+	text := strings.ToLower(resource.Name + " " + resource.Description)
+	for _, word := range strings.Fields(strings.ToLower(opts.Search)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func within(t time.Time, after, before *time.Time) bool {
+	return (after == nil || t.After(*after)) && (before == nil || t.Before(*before))
+}
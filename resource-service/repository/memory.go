@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"context"
+	"resource-service/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository keeps resources, their history, shares and groups in maps,
+// for tests and local runs without Postgres. Its contents are lost on restart.
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	resources map[string]models.Resource
+	versions  map[string][]models.ResourceVersion
+	// shares are keyed by resource ID, in the order they were added
+	shares  map[string][]models.ResourceShare
+	groups  map[string]models.Group
+	members map[string][]string // group ID → user IDs
+	events  []models.ACLEvent
+	// lastShareID numbers shares the way the Postgres sequence would
+	lastShareID uint
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		resources: make(map[string]models.Resource),
+		versions:  make(map[string][]models.ResourceVersion),
+		shares:    make(map[string][]models.ResourceShare),
+		groups:    make(map[string]models.Group),
+		members:   make(map[string][]string),
+	}
+}
+
+// roleOn returns the viewer's role on a resource, or "" when they have none.
+// The caller holds r.mu.
+func (r *MemoryRepository) roleOn(viewer Viewer, resource models.Resource) models.Role {
+	if viewer.Admin || resource.OwnerID == viewer.UserID {
+		return models.RoleOwner
+	}
+	var role models.Role
+	for _, share := range r.shares[resource.ID] {
+		if viewer.sharedWith(share) {
+			role = role.Max(share.Role)
+		}
+	}
+	return role
+}
+
+// record appends an ACL event. The caller holds r.mu for writing.
+func (r *MemoryRepository) record(event models.ACLEvent) {
+	event.ID = uint(len(r.events) + 1)
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, event)
+}
+
+func (r *MemoryRepository) List(ctx context.Context, viewer Viewer, opts ListOptions) (*Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := make([]models.Resource, 0, len(r.resources))
+	for _, resource := range r.resources {
+		if opts.SharedWithMe {
+			if resource.OwnerID == viewer.UserID || !r.sharedWithViewer(viewer, resource.ID) {
+				continue
+			}
+		} else if r.roleOn(viewer, resource) == "" {
+			continue
+		}
+		if opts.matches(resource) {
+			matches = append(matches, resource)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return opts.before(matches[i], matches[j]) })
+
+	page := &Page{Resources: []models.Resource{}, TotalEstimate: int64(len(matches))}
+	if page.TotalEstimate > totalEstimateCap {
+		page.TotalEstimate = totalEstimateCap
+	}
+	for _, resource := range matches {
+		if !opts.pastCursor(resource) {
+			continue
+		}
+		if len(page.Resources) == opts.Limit {
+			page.NextCursor = cursorAfter(page.Resources[len(page.Resources)-1], opts)
+			break
+		}
+		page.Resources = append(page.Resources, resource)
+	}
+	return page, nil
+}
+
+// sharedWithViewer reports whether any share of a resource names the viewer or
+// their groups. The caller holds r.mu.
+func (r *MemoryRepository) sharedWithViewer(viewer Viewer, resourceID string) bool {
+	for _, share := range r.shares[resourceID] {
+		if viewer.sharedWith(share) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error) {
+	return r.get(viewer, id, false)
+}
+
+func (r *MemoryRepository) GetDeleted(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error) {
+	return r.get(viewer, id, true)
+}
+
+func (r *MemoryRepository) get(viewer Viewer, id string, deleted bool) (*models.Resource, models.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resource, ok := r.resources[id]
+	if !ok || (resource.DeletedAt != nil) != deleted {
+		return nil, "", ErrNotFound
+	}
+	role := r.roleOn(viewer, resource)
+	if role == "" {
+		return nil, "", ErrNotFound
+	}
+	return &resource, role, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, resource *models.Resource, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resources[resource.ID] = *resource
+	r.versions[resource.ID] = []models.ResourceVersion{snapshot(*resource, nil, models.VersionCreate, actorID)}
+	return nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, resource *models.Resource, actorID string) error {
+	return r.update(resource, actorID, models.VersionUpdate, 0)
+}
+
+func (r *MemoryRepository) Restore(ctx context.Context, resource *models.Resource, from int64, actorID string) error {
+	return r.update(resource, actorID, models.VersionRestore, from)
+}
+
+func (r *MemoryRepository) update(resource *models.Resource, actorID, action string, from int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.resources[resource.ID]
+	if !ok || existing.DeletedAt != nil || existing.Version != resource.Version {
+		return ErrVersionConflict
+	}
+	previous := existing
+	existing.Name = resource.Name
+	existing.Description = resource.Description
+	existing.UpdatedAt = resource.UpdatedAt
+	existing.Version++
+	r.resources[resource.ID] = existing
+	resource.Version = existing.Version
+
+	version := snapshot(existing, &previous, action, actorID)
+	version.RestoredFrom = from
+	r.versions[resource.ID] = append(r.versions[resource.ID], version)
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id string, version int64, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.resources[id]
+	if !ok || existing.DeletedAt != nil || existing.Version != version {
+		return ErrVersionConflict
+	}
+	now := time.Now()
+	existing.DeletedAt = &now
+	existing.DeletedBy = actorID
+	existing.Version++
+	r.resources[id] = existing
+	r.versions[id] = append(r.versions[id], snapshot(existing, &existing, models.VersionDelete, actorID))
+	return nil
+}
+
+func (r *MemoryRepository) Undelete(ctx context.Context, resource *models.Resource, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.resources[resource.ID]
+	if !ok || existing.DeletedAt == nil || existing.Version != resource.Version {
+		return ErrVersionConflict
+	}
+	existing.DeletedAt = nil
+	existing.DeletedBy = ""
+	existing.Version++
+	r.resources[resource.ID] = existing
+	r.versions[resource.ID] = append(r.versions[resource.ID], snapshot(existing, &existing, models.VersionUndelete, actorID))
+	*resource = existing
+	return nil
+}
+
+func (r *MemoryRepository) Versions(ctx context.Context, resourceID string) ([]models.ResourceVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.ResourceVersion{}, r.versions[resourceID]...), nil
+}
+
+func (r *MemoryRepository) Version(ctx context.Context, resourceID string, version int64) (*models.ResourceVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.versions[resourceID] {
+		if v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, ErrResourceVersionNotFound
+}
+
+func (r *MemoryRepository) Shares(ctx context.Context, resourceID string) ([]models.ResourceShare, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.ResourceShare{}, r.shares[resourceID]...), nil
+}
+
+func (r *MemoryRepository) Share(ctx context.Context, share *models.ResourceShare) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.resources[share.ResourceID]; !ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	share.CreatedAt, share.UpdatedAt = now, now
+
+	shares := r.shares[share.ResourceID]
+	replaced := false
+	for i, existing := range shares {
+		if existing.PrincipalType == share.PrincipalType && existing.PrincipalID == share.PrincipalID {
+			share.ID, share.CreatedAt = existing.ID, existing.CreatedAt
+			shares[i] = *share
+			replaced = true
+		}
+	}
+	if !replaced {
+		r.lastShareID++
+		share.ID = r.lastShareID
+		shares = append(shares, *share)
+	}
+	r.shares[share.ResourceID] = shares
+	r.record(shareEvent(*share))
+	return nil
+}
+
+func (r *MemoryRepository) Unshare(ctx context.Context, resourceID, principalType, principalID, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shares := r.shares[resourceID]
+	for i, share := range shares {
+		if share.PrincipalType == principalType && share.PrincipalID == principalID {
+			r.shares[resourceID] = append(shares[:i:i], shares[i+1:]...)
+			r.record(unshareEvent(share, actorID))
+			return nil
+		}
+	}
+	return ErrShareNotFound
+}
+
+func (r *MemoryRepository) ACLEvents(ctx context.Context, resourceID string) ([]models.ACLEvent, error) {
+	return r.filterEvents(func(e models.ACLEvent) bool { return e.ResourceID == resourceID }), nil
+}
+
+func (r *MemoryRepository) filterEvents(keep func(models.ACLEvent) bool) []models.ACLEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []models.ACLEvent{}
+	for _, event := range r.events {
+		if keep(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (r *MemoryRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.groups[group.ID] = *group
+	r.members[group.ID] = []string{group.OwnerID}
+	r.record(memberEvent(models.ACLMemberAdd, group.ID, group.OwnerID, group.OwnerID))
+	return nil
+}
+
+func (r *MemoryRepository) Group(ctx context.Context, id string) (*models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	group.Members = append([]string{}, r.members[id]...)
+	return &group, nil
+}
+
+func (r *MemoryRepository) GroupsOf(ctx context.Context, userID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []string
+	for groupID, members := range r.members {
+		if contains(members, userID) {
+			ids = append(ids, groupID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *MemoryRepository) ListGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := []models.Group{}
+	for id, group := range r.groups {
+		if contains(r.members[id], userID) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].CreatedAt.Equal(groups[j].CreatedAt) {
+			return groups[i].ID < groups[j].ID
+		}
+		return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+	})
+	return groups, nil
+}
+
+func (r *MemoryRepository) AddMember(ctx context.Context, groupID, userID, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[groupID]; !ok {
+		return ErrGroupNotFound
+	}
+	if contains(r.members[groupID], userID) {
+		return nil
+	}
+	r.members[groupID] = append(r.members[groupID], userID)
+	r.record(memberEvent(models.ACLMemberAdd, groupID, userID, actorID))
+	return nil
+}
+
+func (r *MemoryRepository) RemoveMember(ctx context.Context, groupID, userID, actorID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := r.members[groupID]
+	for i, member := range members {
+		if member == userID {
+			r.members[groupID] = append(members[:i:i], members[i+1:]...)
+			r.record(memberEvent(models.ACLMemberRemove, groupID, userID, actorID))
+			return nil
+		}
+	}
+	return ErrMemberNotFound
+}
+
+func (r *MemoryRepository) GroupEvents(ctx context.Context, groupID string) ([]models.ACLEvent, error) {
+	return r.filterEvents(func(e models.ACLEvent) bool { return e.GroupID == groupID }), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ ResourceRepository = (*MemoryRepository)(nil)
+	_ GroupRepository    = (*MemoryRepository)(nil)
+)
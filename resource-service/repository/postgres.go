@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"resource-service/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresRepository stores resources in the resources table, their history in
+// resource_versions, their shares in resource_shares, groups in resource_groups
+// and resource_group_members, and every ACL change in acl_events
+type PostgresRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRepository(db *gorm.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// sharedWith limits a resource_shares query to shares naming the viewer or their groups
+func sharedWith(db *gorm.DB, viewer Viewer) *gorm.DB {
+	cond := "(principal_type = ? AND principal_id = ?)"
+	args := []interface{}{models.PrincipalUser, viewer.UserID}
+	if len(viewer.Groups) > 0 {
+		cond += " OR (principal_type = ? AND principal_id IN ?)"
+		args = append(args, models.PrincipalGroup, viewer.Groups)
+	}
+	return db.Where(cond, args...)
+}
+
+// filtered starts a resources query limited to the resources the viewer has a
+// role on that match opts, ignoring its sort and cursor
+func (r *PostgresRepository) filtered(ctx context.Context, viewer Viewer, opts ListOptions) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&models.Resource{})
+	if opts.Deleted {
+		db = db.Where("deleted_at IS NOT NULL")
+	} else {
+		db = db.Where("deleted_at IS NULL")
+	}
+	shared := sharedWith(r.db.Model(&models.ResourceShare{}).Select("resource_id"), viewer)
+	switch {
+	case opts.SharedWithMe:
+		db = db.Where("id IN (?) AND owner_id <> ?", shared, viewer.UserID)
+	case !viewer.Admin:
+		db = db.Where("owner_id = ? OR id IN (?)", viewer.UserID, shared)
+	}
+
+	if opts.Name != "" {
+		db = db.Where("name ILIKE ?", "%"+escapeLike(opts.Name)+"%")
+	}
+	if opts.OwnerID != "" {
+		db = db.Where("owner_id = ?", opts.OwnerID)
+	}
+	if opts.CreatedAfter != nil {
+		db = db.Where("created_at > ?", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *opts.CreatedBefore)
+	}
+	if opts.UpdatedAfter != nil {
+		db = db.Where("updated_at > ?", *opts.UpdatedAfter)
+	}
+	if opts.UpdatedBefore != nil {
+		db = db.Where("updated_at < ?", *opts.UpdatedBefore)
+	}
+	if opts.Search != "" {
+		db = db.Where("search_vector @@ websearch_to_tsquery('english', ?)", opts.Search)
+	}
+	return db
+}
+
+func (r *PostgresRepository) List(ctx context.Context, viewer Viewer, opts ListOptions) (*Page, error) {
+	page := &Page{Resources: []models.Resource{}}
+	capped := r.filtered(ctx, viewer, opts).Select("1").Limit(totalEstimateCap)
+	if err := r.db.WithContext(ctx).Table("(?) AS matches", capped).Count(&page.TotalEstimate).Error; err != nil {
+		return nil, err
+	}
+
+	column, direction, op := opts.Sort.column(), "ASC", ">"
+	if opts.Desc {
+		direction, op = "DESC", "<"
+	}
+	db := r.filtered(ctx, viewer, opts)
+	if opts.After != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), opts.After.Value, opts.After.ID)
+	}
+	err := db.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(opts.Limit + 1).
+		Find(&page.Resources).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Resources) > opts.Limit {
+		page.Resources = page.Resources[:opts.Limit]
+		page.NextCursor = cursorAfter(page.Resources[opts.Limit-1], opts)
+	}
+	return page, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error) {
+	return r.get(ctx, viewer, id, "deleted_at IS NULL")
+}
+
+func (r *PostgresRepository) GetDeleted(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error) {
+	return r.get(ctx, viewer, id, "deleted_at IS NOT NULL")
+}
+
+// get loads a resource matching state, a condition on deleted_at, with the viewer's role on it
+func (r *PostgresRepository) get(ctx context.Context, viewer Viewer, id, state string) (*models.Resource, models.Role, error) {
+	var resource models.Resource
+	err := r.db.WithContext(ctx).Where(state).First(&resource, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if viewer.Admin || resource.OwnerID == viewer.UserID {
+		return &resource, models.RoleOwner, nil
+	}
+
+	var roles []models.Role
+	err = sharedWith(r.db.WithContext(ctx).Model(&models.ResourceShare{}).Where("resource_id = ?", id), viewer).
+		Pluck("role", &roles).Error
+	if err != nil {
+		return nil, "", err
+	}
+	var role models.Role
+	for _, shared := range roles {
+		role = role.Max(shared)
+	}
+	if role == "" {
+		return nil, "", ErrNotFound
+	}
+	return &resource, role, nil
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, resource *models.Resource, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(resource).Error; err != nil {
+			return err
+		}
+		version := snapshot(*resource, nil, models.VersionCreate, actorID)
+		return tx.Create(&version).Error
+	})
+}
+
+// lockVersion loads and locks a resource for a write, provided it is still at
+// version and in the state given by a condition on deleted_at
+func lockVersion(tx *gorm.DB, id string, version int64, state string) (*models.Resource, error) {
+	var stored models.Resource
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(state).
+		First(&stored, "id = ? AND version = ?", id, version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionConflict
+	}
+	return &stored, err
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, resource *models.Resource, actorID string) error {
+	return r.update(ctx, resource, actorID, models.VersionUpdate, 0)
+}
+
+func (r *PostgresRepository) Restore(ctx context.Context, resource *models.Resource, from int64, actorID string) error {
+	return r.update(ctx, resource, actorID, models.VersionRestore, from)
+}
+
+func (r *PostgresRepository) update(ctx context.Context, resource *models.Resource, actorID, action string, from int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		previous, err := lockVersion(tx, resource.ID, resource.Version, "deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Resource{ID: resource.ID}).Updates(map[string]interface{}{
+			"name":        resource.Name,
+			"description": resource.Description,
+			"updated_at":  resource.UpdatedAt,
+			"version":     resource.Version + 1,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		updated := *previous
+		updated.Name, updated.Description = resource.Name, resource.Description
+		updated.Version = resource.Version + 1
+		version := snapshot(updated, previous, action, actorID)
+		version.RestoredFrom = from
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		resource.Version++
+		return nil
+	})
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, id string, version int64, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stored, err := lockVersion(tx, id, version, "deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		stored.DeletedAt, stored.DeletedBy, stored.Version = &now, actorID, version+1
+		err = tx.Model(&models.Resource{ID: id}).UpdateColumns(map[string]interface{}{
+			"deleted_at": now,
+			"deleted_by": actorID,
+			"version":    stored.Version,
+		}).Error
+		if err != nil {
+			return err
+		}
+		snap := snapshot(*stored, stored, models.VersionDelete, actorID)
+		return tx.Create(&snap).Error
+	})
+}
+
+func (r *PostgresRepository) Undelete(ctx context.Context, resource *models.Resource, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stored, err := lockVersion(tx, resource.ID, resource.Version, "deleted_at IS NOT NULL")
+		if err != nil {
+			return err
+		}
+		stored.DeletedAt, stored.DeletedBy, stored.Version = nil, "", stored.Version+1
+		err = tx.Model(&models.Resource{ID: stored.ID}).UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": "",
+			"version":    stored.Version,
+		}).Error
+		if err != nil {
+			return err
+		}
+		snap := snapshot(*stored, stored, models.VersionUndelete, actorID)
+		if err := tx.Create(&snap).Error; err != nil {
+			return err
+		}
+		*resource = *stored
+		return nil
+	})
+}
+
+func (r *PostgresRepository) Versions(ctx context.Context, resourceID string) ([]models.ResourceVersion, error) {
+	versions := []models.ResourceVersion{}
+	err := r.db.WithContext(ctx).Where("resource_id = ?", resourceID).Order("version").Find(&versions).Error
+	return versions, err
+}
+
+func (r *PostgresRepository) Version(ctx context.Context, resourceID string, version int64) (*models.ResourceVersion, error) {
+	var v models.ResourceVersion
+	err := r.db.WithContext(ctx).First(&v, "resource_id = ? AND version = ?", resourceID, version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrResourceVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *PostgresRepository) Shares(ctx context.Context, resourceID string) ([]models.ResourceShare, error) {
+	shares := []models.ResourceShare{}
+	err := r.db.WithContext(ctx).Where("resource_id = ?", resourceID).Order("id").Find(&shares).Error
+	return shares, err
+}
+
+func (r *PostgresRepository) Share(ctx context.Context, share *models.ResourceShare) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "resource_id"}, {Name: "principal_type"}, {Name: "principal_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+		}).Create(share).Error
+		if err != nil {
+			return err
+		}
+		event := shareEvent(*share)
+		return tx.Create(&event).Error
+	})
+}
+
+func (r *PostgresRepository) Unshare(ctx context.Context, resourceID, principalType, principalID, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shares []models.ResourceShare
+		err := tx.Clauses(clause.Returning{}).
+			Where("resource_id = ? AND principal_type = ? AND principal_id = ?", resourceID, principalType, principalID).
+			Delete(&shares).Error
+		if err != nil {
+			return err
+		}
+		if len(shares) == 0 {
+			return ErrShareNotFound
+		}
+		event := unshareEvent(shares[0], actorID)
+		return tx.Create(&event).Error
+	})
+}
+
+func (r *PostgresRepository) ACLEvents(ctx context.Context, resourceID string) ([]models.ACLEvent, error) {
+	events := []models.ACLEvent{}
+	err := r.db.WithContext(ctx).Where("resource_id = ?", resourceID).Order("id").Find(&events).Error
+	return events, err
+}
+
+func (r *PostgresRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.GroupMember{GroupID: group.ID, UserID: group.OwnerID}).Error; err != nil {
+			return err
+		}
+		event := memberEvent(models.ACLMemberAdd, group.ID, group.OwnerID, group.OwnerID)
+		return tx.Create(&event).Error
+	})
+}
+
+func (r *PostgresRepository) Group(ctx context.Context, id string) (*models.Group, error) {
+	var group models.Group
+	err := r.db.WithContext(ctx).First(&group, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.WithContext(ctx).Model(&models.GroupMember{}).
+		Where("group_id = ?", id).Order("created_at, user_id").
+		Pluck("user_id", &group.Members).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *PostgresRepository) GroupsOf(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&models.GroupMember{}).
+		Where("user_id = ?", userID).Order("group_id").
+		Pluck("group_id", &ids).Error
+	return ids, err
+}
+
+func (r *PostgresRepository) ListGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	member := r.db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userID)
+	groups := []models.Group{}
+	err := r.db.WithContext(ctx).Where("id IN (?)", member).Order("created_at, id").Find(&groups).Error
+	return groups, err
+}
+
+func (r *PostgresRepository) AddMember(ctx context.Context, groupID, userID, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Group{}).Where("id = ?", groupID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrGroupNotFound
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.GroupMember{GroupID: groupID, UserID: userID})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		event := memberEvent(models.ACLMemberAdd, groupID, userID, actorID)
+		return tx.Create(&event).Error
+	})
+}
+
+func (r *PostgresRepository) RemoveMember(ctx context.Context, groupID, userID, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.GroupMember{}, "group_id = ? AND user_id = ?", groupID, userID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrMemberNotFound
+		}
+		event := memberEvent(models.ACLMemberRemove, groupID, userID, actorID)
+		return tx.Create(&event).Error
+	})
+}
+
+func (r *PostgresRepository) GroupEvents(ctx context.Context, groupID string) ([]models.ACLEvent, error) {
+	events := []models.ACLEvent{}
+	err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("id").Find(&events).Error
+	return events, err
+}
+
+var (
+	_ ResourceRepository = (*PostgresRepository)(nil)
+	_ GroupRepository    = (*PostgresRepository)(nil)
+)
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"resource-service/models"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no resource has the requested ID, or the viewer has no role on it
+	ErrNotFound = errors.New("resource not found")
+	// ErrVersionConflict is returned when a resource changed since the version the caller read
+	ErrVersionConflict = errors.New("resource version conflict")
+	// ErrResourceVersionNotFound is returned when a resource has no history entry with the requested version
+	ErrResourceVersionNotFound = errors.New("resource version not found")
+	// ErrShareNotFound is returned when unsharing a principal the resource is not shared with
+	ErrShareNotFound = errors.New("share not found")
+	// ErrGroupNotFound is returned when no group has the requested ID
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrMemberNotFound is returned when removing a user who is not in the group
+	ErrMemberNotFound = errors.New("group member not found")
+)
+
+// Viewer is the caller a repository call is made for: a user, the groups they
+// belong to, and whether they are an admin. Admins hold the owner role on every resource.
+type Viewer struct {
+	UserID string
+	Groups []string
+	Admin  bool
+}
+
+// ResourceRepository stores resources, their version history and who they are
+// shared with. Implementations are safe for concurrent use. Resources the viewer
+// has no role on are reported as ErrNotFound, so their existence is not revealed.
+//
+// Every write records a snapshot in the version history, numbered with the
+// resource's new version, in the same transaction as the write.
+type ResourceRepository interface {
+	// List returns one page of the resources the viewer has a role on that match opts
+	List(ctx context.Context, viewer Viewer, opts ListOptions) (*Page, error)
+	// Get returns a resource that is not in the trash and the viewer's role on it:
+	// owner for the owner and admins, otherwise the strongest role shared with the
+	// viewer or their groups
+	Get(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error)
+	// GetDeleted is Get for resources in the trash
+	GetDeleted(ctx context.Context, viewer Viewer, id string) (*models.Resource, models.Role, error)
+	// Create stores a new resource as version 1, created by actorID
+	Create(ctx context.Context, resource *models.Resource, actorID string) error
+	// Update stores the name, description and updated_at of a resource if its stored
+	// version is still resource.Version, and advances resource.Version.
+	// It returns ErrVersionConflict when the resource has changed or is gone.
+	Update(ctx context.Context, resource *models.Resource, actorID string) error
+	// Restore is Update recorded as a restore of the given earlier version
+	Restore(ctx context.Context, resource *models.Resource, from int64, actorID string) error
+	// Delete moves a resource to the trash if its stored version is still version.
+	// Its shares are kept for an undelete. It returns ErrVersionConflict when the
+	// resource has changed or is gone.
+	Delete(ctx context.Context, id string, version int64, actorID string) error
+	// Undelete takes a resource out of the trash and advances resource.Version
+	Undelete(ctx context.Context, resource *models.Resource, actorID string) error
+
+	// Versions returns a resource's history, oldest first
+	Versions(ctx context.Context, resourceID string) ([]models.ResourceVersion, error)
+	// Version returns one entry of a resource's history
+	Version(ctx context.Context, resourceID string, version int64) (*models.ResourceVersion, error)
+
+	// Shares lists who a resource is shared with, in the order they were added
+	Shares(ctx context.Context, resourceID string) ([]models.ResourceShare, error)
+	// Share grants share.Role to its principal, replacing any role they had,
+	// and records the change as made by share.GrantedBy
+	Share(ctx context.Context, share *models.ResourceShare) error
+	// Unshare revokes a principal's role and records the change as made by actorID
+	Unshare(ctx context.Context, resourceID, principalType, principalID, actorID string) error
+	// ACLEvents returns the sharing history of a resource, oldest first
+	ACLEvents(ctx context.Context, resourceID string) ([]models.ACLEvent, error)
+}
+
+// GroupRepository stores the groups resources can be shared with
+type GroupRepository interface {
+	// CreateGroup stores a group with its owner as the first member
+	CreateGroup(ctx context.Context, group *models.Group) error
+	// Group returns a group with its members
+	Group(ctx context.Context, id string) (*models.Group, error)
+	// GroupsOf returns the IDs of the groups userID is a member of
+	GroupsOf(ctx context.Context, userID string) ([]string, error)
+	// ListGroups returns the groups userID is a member of, oldest first
+	ListGroups(ctx context.Context, userID string) ([]models.Group, error)
+	// AddMember puts userID in a group and records the change as made by actorID.
+	// Adding an existing member does nothing.
+	AddMember(ctx context.Context, groupID, userID, actorID string) error
+	// RemoveMember takes userID out of a group and records the change as made by actorID
+	RemoveMember(ctx context.Context, groupID, userID, actorID string) error
+	// GroupEvents returns the membership history of a group, oldest first
+	GroupEvents(ctx context.Context, groupID string) ([]models.ACLEvent, error)
+}
+
+// sharedWith reports whether share names the viewer or one of their groups
+func (v Viewer) sharedWith(share models.ResourceShare) bool {
+	if share.PrincipalType == models.PrincipalUser {
+		return share.PrincipalID == v.UserID
+	}
+	if share.PrincipalType != models.PrincipalGroup {
+		return false
+	}
+	for _, group := range v.Groups {
+		if share.PrincipalID == group {
+			return true
+		}
+	}
+	return false
+}
+
+func shareEvent(share models.ResourceShare) models.ACLEvent {
+	return models.ACLEvent{
+		Action:        models.ACLShare,
+		ResourceID:    share.ResourceID,
+		PrincipalType: share.PrincipalType,
+		PrincipalID:   share.PrincipalID,
+		Role:          share.Role,
+		ActorID:       share.GrantedBy,
+	}
+}
+
+func unshareEvent(share models.ResourceShare, actorID string) models.ACLEvent {
+	return models.ACLEvent{
+		Action:        models.ACLUnshare,
+		ResourceID:    share.ResourceID,
+		PrincipalType: share.PrincipalType,
+		PrincipalID:   share.PrincipalID,
+		Role:          share.Role,
+		ActorID:       actorID,
+	}
+}
+
+func memberEvent(action, groupID, userID, actorID string) models.ACLEvent {
+	return models.ACLEvent{
+		Action:        action,
+		GroupID:       groupID,
+		PrincipalType: models.PrincipalUser,
+		PrincipalID:   userID,
+		ActorID:       actorID,
+	}
+}
+
+// snapshot records resource as it is after a change. previous is the resource
+// before the change, nil for a create; the diff compares the two.
+func snapshot(resource models.Resource, previous *models.Resource, action, actorID string) models.ResourceVersion {
+	before := models.Resource{}
+	if previous != nil {
+		before = *previous
+	}
+	diff := map[string]models.FieldChange{}
+	if before.Name != resource.Name {
+		diff["name"] = models.FieldChange{From: before.Name, To: resource.Name}
+	}
+	if before.Description != resource.Description {
+		diff["description"] = models.FieldChange{From: before.Description, To: resource.Description}
+	}
+	return models.ResourceVersion{
+		ResourceID:  resource.ID,
+		Version:     resource.Version,
+		Action:      action,
+		Name:        resource.Name,
+		Description: resource.Description,
+		Diff:        diff,
+		ActorID:     actorID,
+		CreatedAt:   time.Now(),
+	}
+}